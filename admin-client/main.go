@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/job"
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+const usage = `usage: admin-client <command> [arguments]
+
+commands:
+  migrate status     show all migrations and whether they are applied, and
+                     the watch dates that could not be converted
+  migrate up         apply all pending migrations
+  migrate down       revert the latest applied migration
+  migrate to <n>     migrate up or down to version n
+  jobs list [status] show the jobs in the queue, with their attempts and
+                     the last error, optionally only those with the status
+  jobs add [-in <duration>] [-at <yyyy-mm-dd hh:mm>] [-priority <n>] <action> [<id>]
+                     add a job, to run now or later, by default with a high
+                     priority
+  jobs retry <id>    put a failed job back in the queue
+  jobs delete <id>   remove a job from the queue
+  schedules list     show the schedules and when they run next
+  schedules add [-tz <zone>] <name> <cron> <action> [<id>]
+                     add a job every time the cron expression matches, or
+                     change the schedule with that name. The expression is
+                     read in the time zone, UTC by default
+  schedules delete <name>
+                     remove a schedule
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "jobs":
+		err = jobs(os.Args[2:])
+	case "schedules":
+		err = schedules(os.Args[2:])
+	default:
+		fmt.Print(usage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// openDB opens the database as it is. The admin client leaves the schema
+// alone, except for the migrate command.
+func openDB() (storage.Backend, error) {
+	conf := storage.ConfigFromEnv()
+	conf.AutoMigrate = false
+
+	return storage.Open(conf)
+}
+
+func migrate(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing migrate command\n\n%s", usage)
+	}
+
+	m, err := storage.OpenMigrator(storage.ConfigFromEnv())
+	if err != nil {
+		return fmt.Errorf("could not open database: %w", err)
+	}
+
+	switch args[0] {
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			line := fmt.Sprintf("%4d  pending", s.Version)
+			if s.Applied {
+				line = fmt.Sprintf("%4d  applied at %s", s.Version, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+			if s.Modified {
+				line += "  (changed after it was applied)"
+			}
+			fmt.Println(line)
+		}
+		unparsed, err := m.UnparsedDates()
+		if err != nil {
+			return err
+		}
+		for _, u := range unparsed {
+			fmt.Printf("could not parse watch date %q of %s %s, please set it again\n", u.Value, u.Table, u.ID)
+		}
+		return nil
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down()
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version\n\n%s", usage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version: %w", convErr)
+		}
+		err = m.To(version)
+	default:
+		return fmt.Errorf("unknown migrate command: %s\n\n%s", args[0], usage)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("database is at version %d of %d\n", current, m.Latest())
+
+	return nil
+}
+
+func jobs(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing jobs command\n\n%s", usage)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("could not open database: %w", err)
+	}
+	jq, err := job.NewJobQueue(db, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "list":
+		all, err := jq.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, j := range all {
+			if len(args) > 1 && string(j.Status) != args[1] {
+				continue
+			}
+			line := fmt.Sprintf("%6d  %-7s %-25s %-36s priority %-3d attempt %d of %d", j.ID, j.Status, j.Action, j.ActionID, j.Priority, j.Attempts, job.MaxAttempts(j.Action))
+			if j.Worker != "" {
+				line += fmt.Sprintf("  on %s", j.Worker)
+			}
+			if j.Status == "todo" && !j.RunAt.IsZero() {
+				line += fmt.Sprintf("  runs at %s", j.RunAt.Local().Format("2006-01-02 15:04:05"))
+			}
+			if j.LastError != "" {
+				line += fmt.Sprintf("\n        last error: %s", strings.ReplaceAll(j.LastError, "\n", " "))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	case "add":
+		fs := flag.NewFlagSet("jobs add", flag.ContinueOnError)
+		in := fs.Duration("in", 0, "run the job after this long")
+		at := fs.String("at", "", "run the job at this time, as yyyy-mm-dd hh:mm")
+		priority := fs.Int("priority", job.PriorityHigh, "jobs with a higher priority go first")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() < 1 {
+			return fmt.Errorf("missing action\n\n%s", usage)
+		}
+		var runAt time.Time
+		switch {
+		case *at != "":
+			if runAt, err = time.ParseInLocation("2006-01-02 15:04", *at, time.Local); err != nil {
+				return fmt.Errorf("invalid time: %w", err)
+			}
+		case *in != 0:
+			runAt = time.Now().Add(*in)
+		}
+		return jq.Enqueue(ctx, job.Job{ActionID: fs.Arg(1), Action: fs.Arg(0), RunAt: runAt, Priority: *priority})
+	case "retry", "delete":
+		if len(args) < 2 {
+			return fmt.Errorf("missing job id\n\n%s", usage)
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid job id: %w", err)
+		}
+		if args[0] == "delete" {
+			return jq.Delete(ctx, args[1])
+		}
+		return jq.Requeue(ctx, id)
+	default:
+		return fmt.Errorf("unknown jobs command: %s\n\n%s", args[0], usage)
+	}
+}
+
+func schedules(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing schedules command\n\n%s", usage)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return fmt.Errorf("could not open database: %w", err)
+	}
+	scheduleRepo, err := job.NewScheduleRepository(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "list":
+		all, err := scheduleRepo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range all {
+			fmt.Printf("%-20s %-15s %-20s %-25s %-36s next at %s\n", s.Name, s.Cron, s.TimeZone, s.Action, s.ActionID, s.NextRun.Local().Format("2006-01-02 15:04"))
+		}
+		return nil
+	case "add":
+		fs := flag.NewFlagSet("schedules add", flag.ContinueOnError)
+		tz := fs.String("tz", job.DefaultTimeZone, "read the cron expression in this time zone, like Europe/Amsterdam")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() < 3 {
+			return fmt.Errorf("missing name, cron expression or action\n\n%s", usage)
+		}
+		s, err := job.NewSchedule(fs.Arg(0), fs.Arg(1), *tz, fs.Arg(2), fs.Arg(3), time.Now())
+		if err != nil {
+			return err
+		}
+		if err := scheduleRepo.Store(ctx, s); err != nil {
+			return err
+		}
+		fmt.Printf("schedule %s runs next at %s\n", s.Name, s.NextRun.Local().Format("2006-01-02 15:04"))
+		return nil
+	case "delete":
+		if len(args) < 2 {
+			return fmt.Errorf("missing name\n\n%s", usage)
+		}
+		return scheduleRepo.Delete(ctx, args[1])
+	default:
+		return fmt.Errorf("unknown schedules command: %s\n\n%s", args[0], usage)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	tmdb "github.com/cyruzin/golang-tmdb"
+)
+
+// statusError sorts an unexpected HTTP status in the error kinds of the
+// storage package, so the worker knows whether to try again.
+func statusError(service string, status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return fmt.Errorf("%w: %s: status code %d", storage.ErrNotFound, service, status)
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %s: status code %d", storage.ErrUnavailable, service, status)
+	default:
+		return fmt.Errorf("%s: unexpected status code: %d", service, status)
+	}
+}
+
+// tmdbError does the same for the errors of the TMDB API. Errors that do not
+// come from the API, like a failing network, are worth another try.
+func tmdbError(err error) error {
+	var apiErr tmdb.Error
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("%w: tmdb: %v", storage.ErrUnavailable, err)
+	}
+	switch apiErr.StatusCode {
+	case 6, 34: // invalid id, resource not found
+		return fmt.Errorf("%w: tmdb: %v", storage.ErrNotFound, err)
+	case 9, 11, 24, 25: // service offline, internal error, backend timeout, rate limit
+		return fmt.Errorf("%w: tmdb: %v", storage.ErrUnavailable, err)
+	default:
+		return fmt.Errorf("tmdb: %w", err)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+const (
+	tmdbImageBaseURL = "https://image.tmdb.org/t/p"
+	imageTimeout     = 30 * time.Second
+)
+
+// TMDBImageURL returns the URL of an image at TMDB, like the poster of a
+// movie, in one of the sizes that TMDB offers, like "w500".
+func TMDBImageURL(path, size string) string {
+	return fmt.Sprintf("%s/%s%s", tmdbImageBaseURL, size, path)
+}
+
+// Images downloads images. Images at TMDB need no API key.
+type Images struct {
+	c *http.Client
+}
+
+func NewImages() *Images {
+	return &Images{
+		c: &http.Client{Timeout: imageTimeout},
+	}
+}
+
+func (i *Images) Download(url string) ([]byte, error) {
+	res, err := i.c.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: images: %v", storage.ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, statusError("images", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: images: %v", storage.ErrUnavailable, err)
+	}
+
+	return data, nil
+}
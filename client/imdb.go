@@ -28,15 +28,15 @@ func (i *IMDB) GetReviews(m storage.Movie) ([]storage.Review, error) {
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: imdb: %v", storage.ErrUnavailable, err)
 	}
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+		return nil, statusError("imdb", res.StatusCode)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: imdb: %v", storage.ErrUnavailable, err)
 	}
 	defer res.Body.Close()
 
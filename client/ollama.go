@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"go-mod.ewintr.nl/emdb/storage"
 )
 
 type Ollama struct {
@@ -44,14 +46,17 @@ func (o *Ollama) Generate(model, prompt string) (string, error) {
 	}
 	res, err := o.c.Do(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: ollama: %v", storage.ErrUnavailable, err)
 	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: ollama: %v", storage.ErrUnavailable, err)
 	}
 	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", statusError("ollama", res.StatusCode)
+	}
 
 	resBody := struct {
 		Response string
@@ -1,12 +1,18 @@
 package client
 
 import (
+	"fmt"
 	"time"
 
 	"go-mod.ewintr.nl/emdb/storage"
 	tmdb "github.com/cyruzin/golang-tmdb"
 )
 
+const (
+	// castLimit is the number of top billed actors that is kept
+	castLimit = 10
+)
+
 type TMDB struct {
 	c *tmdb.Client
 }
@@ -27,7 +33,7 @@ func NewTMDB(apikey string) (*TMDB, error) {
 func (t TMDB) Search(query string) ([]storage.Movie, error) {
 	results, err := t.c.GetSearchMovies(query, nil)
 	if err != nil {
-		return nil, err
+		return nil, tmdbError(err)
 	}
 
 	movies := make([]storage.Movie, len(results.Results))
@@ -46,7 +52,7 @@ func (t TMDB) GetMovie(id int64) (storage.Movie, error) {
 		"append_to_response": "credits",
 	})
 	if err != nil {
-		return storage.Movie{}, err
+		return storage.Movie{}, tmdbError(err)
 	}
 
 	var year int
@@ -54,21 +60,101 @@ func (t TMDB) GetMovie(id int64) (storage.Movie, error) {
 		year = release.Year()
 	}
 
-	directors := make([]string, 0)
-	for crew := range result.Credits.Crew {
-		if result.Credits.Crew[crew].Job == "Director" {
-			directors = append(directors, result.Credits.Crew[crew].Name)
+	directors := make([]storage.Person, 0)
+	crew := make([]storage.Credit, 0)
+	for _, c := range result.Credits.Crew {
+		if c.Job == "Director" {
+			directors = append(directors, storage.Person{
+				TMDBID: c.ID,
+				Name:   c.Name,
+			})
+			continue
+		}
+		role, ok := crewRole(c.Department, c.Job)
+		if !ok {
+			continue
+		}
+		crew = addCredit(crew, storage.Credit{
+			Person: storage.Person{TMDBID: c.ID, Name: c.Name},
+			Role:   role,
+			Job:    c.Job,
+		})
+	}
+
+	cast := make([]storage.Credit, 0)
+	for _, c := range result.Credits.Cast {
+		if c.Order >= castLimit {
+			continue
 		}
+		cast = addCredit(cast, storage.Credit{
+			Person:    storage.Person{TMDBID: c.ID, Name: c.Name},
+			Role:      storage.RoleCast,
+			Character: c.Character,
+		})
+	}
+
+	genres := make([]string, 0, len(result.Genres))
+	for _, g := range result.Genres {
+		genres = append(genres, g.Name)
+	}
+	countries := make([]string, 0, len(result.ProductionCountries))
+	for _, c := range result.ProductionCountries {
+		countries = append(countries, c.Name)
 	}
 
 	return storage.Movie{
-		Title:        result.OriginalTitle,
-		EnglishTitle: result.Title,
-		TMDBID:       result.ID,
-		IMDBID:       result.IMDbID,
-		Year:         year,
-		Directors:    directors,
-		Summary:      result.Overview,
+		Title:            result.OriginalTitle,
+		EnglishTitle:     result.Title,
+		TMDBID:           result.ID,
+		IMDBID:           result.IMDbID,
+		Year:             year,
+		Directors:        directors,
+		Crew:             crew,
+		Cast:             cast,
+		Summary:          result.Overview,
+		Runtime:          result.Runtime,
+		Genres:           genres,
+		Countries:        countries,
+		OriginalLanguage: result.OriginalLanguage,
+		Tagline:          result.Tagline,
+		VoteAverage:      float64(result.VoteAverage),
+		VoteCount:        int(result.VoteCount),
+		PosterPath:       result.PosterPath,
+		BackdropPath:     result.BackdropPath,
 	}, nil
 
 }
+
+func crewRole(department, job string) (storage.Role, bool) {
+	switch {
+	case department == "Writing":
+		return storage.RoleWriter, true
+	case job == "Director of Photography":
+		return storage.RoleCinematographer, true
+	case job == "Original Music Composer" || job == "Music":
+		return storage.RoleComposer, true
+	case job == "Editor":
+		return storage.RoleEditor, true
+	default:
+		return "", false
+	}
+}
+
+// addCredit merges credits for the same person in the same role, like a
+// writer that is credited for both the story and the screenplay.
+func addCredit(credits []storage.Credit, credit storage.Credit) []storage.Credit {
+	for i, c := range credits {
+		if c.Person.TMDBID != credit.Person.TMDBID || c.Role != credit.Role {
+			continue
+		}
+		if credit.Job != "" {
+			credits[i].Job = fmt.Sprintf("%s, %s", c.Job, credit.Job)
+		}
+		if credit.Character != "" {
+			credits[i].Character = fmt.Sprintf("%s / %s", c.Character, credit.Character)
+		}
+		return credits
+	}
+
+	return append(credits, credit)
+}
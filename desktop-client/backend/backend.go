@@ -1,22 +1,28 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"go-mod.ewintr.nl/emdb/client"
+	"go-mod.ewintr.nl/emdb/imagecache"
 	"go-mod.ewintr.nl/emdb/storage"
 )
 
+const moviePageSize = 200
+
 type Backend struct {
 	s         *State
 	in        chan Command
 	out       chan State
 	logLines  []string
-	movieRepo *storage.MovieRepository
+	movieRepo storage.MovieRepository
 	tmdb      *client.TMDB
+	images    *imagecache.Cache
 }
 
-func NewBackend(movieRepo *storage.MovieRepository, tmdb *client.TMDB) *Backend {
+func NewBackend(movieRepo storage.MovieRepository, tmdb *client.TMDB, images *imagecache.Cache) *Backend {
 	b := &Backend{
 		s:         NewState(),
 		in:        make(chan Command),
@@ -24,6 +30,7 @@ func NewBackend(movieRepo *storage.MovieRepository, tmdb *client.TMDB) *Backend
 		logLines:  make([]string, 0),
 		movieRepo: movieRepo,
 		tmdb:      tmdb,
+		images:    images,
 	}
 	go b.Run()
 
@@ -54,6 +61,17 @@ func (b *Backend) Run() {
 			}
 		case CommandRefreshWatched:
 			b.RefreshWatched()
+		case CommandMoreWatched:
+			cursor, _ := cmd.Args[ArgCursor].(string)
+			b.MoreWatched(cursor)
+		case CommandPoster:
+			if err, ok := cmd.Args[ArgError].(error); ok {
+				b.Error(err)
+				break
+			}
+			movieID, _ := cmd.Args[ArgMovieID].(string)
+			file, _ := cmd.Args[ArgFile].(string)
+			b.SetPoster(movieID, file)
 		default:
 			b.Error(fmt.Errorf("unknown command: %s", cmd.Name))
 		}
@@ -62,12 +80,82 @@ func (b *Backend) Run() {
 	}
 }
 
+// RefreshWatched loads the first page of watched movies, the most recent
+// first.
 func (b *Backend) RefreshWatched() {
-	watched, err := b.movieRepo.FindAll()
+	page, err := b.watchedPage("")
 	if err != nil {
 		b.Error(fmt.Errorf("could not refresh watched: %w", err))
+		return
+	}
+	b.s.Watched = page.Movies
+	b.s.WatchedTotal = page.Total
+	b.s.WatchedNext = page.Next
+
+	go b.fetchPosters(page.Movies)
+}
+
+// MoreWatched adds the page of watched movies that starts at the cursor. A
+// cursor that is not the next one, because the page was asked for twice or
+// the list was refreshed in between, is ignored.
+func (b *Backend) MoreWatched(cursor string) {
+	if cursor == "" || cursor != b.s.WatchedNext {
+		return
+	}
+	page, err := b.watchedPage(cursor)
+	if err != nil {
+		b.Error(fmt.Errorf("could not load more watched: %w", err))
+		return
+	}
+	// a new slice, as the gui still reads the one it got before
+	watched := make([]storage.Movie, 0, len(b.s.Watched)+len(page.Movies))
+	b.s.Watched = append(append(watched, b.s.Watched...), page.Movies...)
+	b.s.WatchedTotal = page.Total
+	b.s.WatchedNext = page.Next
+
+	go b.fetchPosters(page.Movies)
+}
+
+func (b *Backend) watchedPage(cursor string) (storage.MoviePage, error) {
+	return b.movieRepo.Query(context.Background(), storage.MovieQuery{
+		Statuses: []storage.Status{storage.StatusWatched},
+		Sort:     []storage.Sort{{Field: storage.SortWatchedOn, Desc: true}},
+		Limit:    moviePageSize,
+		Cursor:   cursor,
+	})
+}
+
+// fetchPosters downloads the posters that are not in the cache yet, so the
+// list shows before they are all there. Each poster is sent back to Run as
+// a command, as only Run changes the state.
+func (b *Backend) fetchPosters(movies []storage.Movie) {
+	ctx := context.Background()
+	for _, m := range movies {
+		file, err := b.images.Poster(ctx, m)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+		case err != nil:
+			b.in <- Command{Name: CommandPoster, Args: map[string]any{
+				ArgError: fmt.Errorf("could not get poster of %s: %w", m.Title, err),
+			}}
+		default:
+			b.in <- Command{Name: CommandPoster, Args: map[string]any{
+				ArgMovieID: m.ID,
+				ArgFile:    file,
+			}}
+		}
+	}
+}
+
+// SetPoster stores the poster file of the movie. The map is replaced
+// instead of changed, as the gui still reads the one it got before.
+func (b *Backend) SetPoster(movieID, file string) {
+	posters := make(map[string]string, len(b.s.Posters)+1)
+	for id, f := range b.s.Posters {
+		posters[id] = f
 	}
-	b.s.Watched = watched
+	posters[movieID] = file
+	b.s.Posters = posters
 }
 
 func (b *Backend) Error(err error) {
@@ -3,8 +3,18 @@ package backend
 const (
 	CommandAdd            = "add"
 	CommandRefreshWatched = "refreshWatched"
+	// CommandMoreWatched loads the next page of watched movies. ArgCursor
+	// is the WatchedNext of the state.
+	CommandMoreWatched = "moreWatched"
+	// CommandPoster is sent by the backend itself, when a poster was
+	// fetched in the background.
+	CommandPoster = "poster"
 
-	ArgName = "name"
+	ArgName    = "name"
+	ArgMovieID = "movieID"
+	ArgFile    = "file"
+	ArgError   = "error"
+	ArgCursor  = "cursor"
 )
 
 type CommandName string
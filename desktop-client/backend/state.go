@@ -5,13 +5,21 @@ import (
 )
 
 type State struct {
-	Watched []storage.Movie
+	// Watched holds the pages of watched movies that are loaded so far.
+	// WatchedTotal counts all of them and WatchedNext is the cursor for the
+	// next page, empty when all are loaded.
+	Watched      []storage.Movie
+	WatchedTotal int
+	WatchedNext  string
+	// Posters holds the local poster files by movie id.
+	Posters map[string]string
 	Log     []string
 }
 
 func NewState() *State {
 	return &State{
 		Watched: make([]storage.Movie, 0),
+		Posters: make(map[string]string),
 		Log:     make([]string, 0),
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"go-mod.ewintr.nl/emdb/desktop-client/backend"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/layout"
@@ -49,10 +50,12 @@ func (g *GUI) Run() {
 
 func (g *GUI) Update(bs backend.State) {
 	// watched
-	watched := make([]string, 0, len(bs.Watched))
+	watched := make([]any, 0, len(bs.Watched))
 	for _, m := range bs.Watched {
-		watched = append(watched, m.EnglishTitle)
+		watched = append(watched, watchedMovie{Title: m.EnglishTitle, Poster: bs.Posters[m.ID]})
 	}
+	// the cursor first, as setting the movies redraws the list
+	g.s.WatchedNext.Set(bs.WatchedNext)
 	g.s.Watched.Set(watched)
 
 	// log
@@ -84,13 +87,34 @@ func (g *GUI) SetContent() {
 	logLines := container.NewVScroll(widget.NewLabelWithData(g.s.Log))
 	//logLines.ScrollToBottom()
 
-	list := widget.NewListWithData(g.s.Watched,
+	list := widget.NewList(g.s.Watched.Length,
 		func() fyne.CanvasObject {
-			return widget.NewLabel("template")
+			poster := canvas.NewImageFromFile("")
+			poster.FillMode = canvas.ImageFillContain
+			poster.SetMinSize(fyne.NewSize(40, 60))
+			return container.NewBorder(nil, nil, poster, nil, widget.NewLabel("template"))
 		},
-		func(i binding.DataItem, o fyne.CanvasObject) {
-			o.(*widget.Label).Bind(i.(binding.String))
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			v, err := g.s.Watched.GetValue(id)
+			if err != nil {
+				return
+			}
+			m := v.(watchedMovie)
+			for _, obj := range o.(*fyne.Container).Objects {
+				switch obj := obj.(type) {
+				case *canvas.Image:
+					obj.File = m.Poster
+					obj.Refresh()
+				case *widget.Label:
+					obj.SetText(m.Title)
+				}
+			}
+			// the last movie is in view, so load the next page
+			if id == g.s.Watched.Length()-1 {
+				g.moreWatched()
+			}
 		})
+	g.s.Watched.AddListener(binding.NewDataListener(list.Refresh))
 
 	tabs := container.NewAppTabs(
 		container.NewTabItem("Watched", list),
@@ -100,3 +124,20 @@ func (g *GUI) SetContent() {
 
 	g.w.SetContent(tabs)
 }
+
+// moreWatched asks the backend for the next page of watched movies, if there
+// is one. The backend ignores a page that was already asked for.
+func (g *GUI) moreWatched() {
+	next, err := g.s.WatchedNext.Get()
+	if err != nil || next == "" {
+		return
+	}
+	go func() {
+		g.out <- backend.Command{
+			Name: backend.CommandMoreWatched,
+			Args: map[string]any{
+				backend.ArgCursor: next,
+			},
+		}
+	}()
+}
@@ -3,17 +3,25 @@ package gui
 import "fyne.io/fyne/v2/data/binding"
 
 type State struct {
-	Watched binding.StringList
-	Log     binding.String
+	// Watched holds a watchedMovie for each watched movie.
+	Watched binding.UntypedList
+	// WatchedNext is the cursor for the next page of watched movies, empty
+	// when all are loaded.
+	WatchedNext binding.String
+	Log         binding.String
 }
 
-func NewState() *State {
-	watched := binding.BindStringList(
-		&[]string{"Item 1", "Item 2", "Item 3"},
-	)
+// watchedMovie is a line in the list of watched movies. Poster is the local
+// file of the poster, or empty while there is none.
+type watchedMovie struct {
+	Title  string
+	Poster string
+}
 
+func NewState() *State {
 	return &State{
-		Watched: watched,
-		Log:     binding.NewString(),
+		Watched:     binding.NewUntypedList(),
+		WatchedNext: binding.NewString(),
+		Log:         binding.NewString(),
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"go-mod.ewintr.nl/emdb/client"
 	"go-mod.ewintr.nl/emdb/desktop-client/backend"
 	"go-mod.ewintr.nl/emdb/desktop-client/gui"
+	"go-mod.ewintr.nl/emdb/imagecache"
 	"go-mod.ewintr.nl/emdb/storage"
 )
 
@@ -16,19 +17,20 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	dbHost := os.Getenv("EMDB_DB_HOST")
-	dbName := os.Getenv("EMDB_DB_NAME")
-	dbUser := os.Getenv("EMDB_DB_USER")
-	dbPassword := os.Getenv("EMDB_DB_PASSWORD")
-	pgConnStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPassword, dbName)
-	dbPostgres, err := storage.NewPostgres(pgConnStr)
+	db, err := storage.Open(storage.ConfigFromEnv())
 	if err != nil {
-		fmt.Printf("could not create new postgres repo: %s", err.Error())
+		fmt.Printf("could not open database: %s", err.Error())
 		os.Exit(1)
 	}
-	movieRepo := storage.NewMovieRepository(dbPostgres)
+	movieRepo := db.Movies()
+	imageDir, err := imagecache.DirFromEnv()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	images := imagecache.New(imageDir, db.Images(), client.NewImages())
 
-	b := backend.NewBackend(movieRepo, tmdb)
+	b := backend.NewBackend(movieRepo, tmdb, images)
 	g := gui.New(b.In(), b.Out())
 	g.Run()
 }
@@ -11,6 +11,7 @@ require (
 	github.com/cyruzin/golang-tmdb v1.6.0
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/muesli/termenv v0.15.2
 )
 
@@ -0,0 +1,156 @@
+package imagecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/client"
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+const (
+	PosterSize   = "w500"
+	BackdropSize = "w1280"
+)
+
+// Downloader gets the content of an image.
+type Downloader interface {
+	Download(url string) ([]byte, error)
+}
+
+// Cache keeps local copies of images, so they are downloaded from TMDB only
+// once. The images are stored in a directory, each in a file named after the
+// hash of its content. The database tracks which URL is in which file.
+type Cache struct {
+	dir        string
+	imageRepo  storage.ImageRepository
+	downloader Downloader
+}
+
+func New(dir string, imageRepo storage.ImageRepository, downloader Downloader) *Cache {
+	return &Cache{
+		dir:        dir,
+		imageRepo:  imageRepo,
+		downloader: downloader,
+	}
+}
+
+// DirFromEnv returns the directory in EMDB_IMAGE_DIR, or one in the cache
+// directory of the user when it is not set.
+func DirFromEnv() (string, error) {
+	if dir := os.Getenv("EMDB_IMAGE_DIR"); dir != "" {
+		return dir, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "emdb", "images"), nil
+}
+
+// Lookup returns the local file of an image that was downloaded before. It
+// fails with storage.ErrNotFound when there is none.
+func (c *Cache) Lookup(ctx context.Context, url string) (string, error) {
+	img, err := c.imageRepo.FindByURL(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(c.dir, img.File)
+	if _, err := os.Stat(file); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: image file %s", storage.ErrNotFound, img.File)
+		}
+		return "", err
+	}
+
+	return file, nil
+}
+
+// Get returns the local file of the image and downloads it first when it is
+// not in the cache.
+func (c *Cache) Get(ctx context.Context, url string) (string, error) {
+	file, err := c.Lookup(ctx, url)
+	switch {
+	case err == nil:
+		return file, nil
+	case !errors.Is(err, storage.ErrNotFound):
+		return "", err
+	}
+
+	data, err := c.downloader.Download(url)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	img := storage.Image{
+		URL:       url,
+		Hash:      hash,
+		File:      filepath.Join(hash[:2], hash+path.Ext(url)),
+		Size:      int64(len(data)),
+		FetchedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	file = filepath.Join(c.dir, img.File)
+	if err := write(file, data); err != nil {
+		return "", err
+	}
+	if err := c.imageRepo.Store(ctx, img); err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+// Poster returns the local file of the poster of the movie. It fails with
+// storage.ErrNotFound when the movie has no poster.
+func (c *Cache) Poster(ctx context.Context, m storage.Movie) (string, error) {
+	if m.PosterPath == "" {
+		return "", fmt.Errorf("%w: movie %s has no poster", storage.ErrNotFound, m.ID)
+	}
+
+	return c.Get(ctx, client.TMDBImageURL(m.PosterPath, PosterSize))
+}
+
+// Backdrop does the same for the backdrop of the movie.
+func (c *Cache) Backdrop(ctx context.Context, m storage.Movie) (string, error) {
+	if m.BackdropPath == "" {
+		return "", fmt.Errorf("%w: movie %s has no backdrop", storage.ErrNotFound, m.ID)
+	}
+
+	return c.Get(ctx, client.TMDBImageURL(m.BackdropPath, BackdropSize))
+}
+
+// write stores the data in the file, unless the same content is already
+// there. The data is written to a temporary file first, so an interrupted
+// download does not leave half an image.
+func write(file string, data []byte) error {
+	if _, err := os.Stat(file); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "download-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,156 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+// Cron is a parsed cron expression with the usual five fields: minute, hour,
+// day of the month, month and day of the week. Fields can be *, a number, a
+// range like 1-5, a step like */15 or 1-30/2, or a comma separated list of
+// those. Sunday is 0 or 7. The shorthands @hourly, @daily, @weekly and
+// @monthly are accepted too.
+type Cron struct {
+	expr    string
+	minutes []bool
+	hours   []bool
+	days    []bool
+	months  []bool
+	weekday []bool
+	// anyDay and anyWeekday tell whether the day fields were *. When both
+	// are restricted, a time matches if either of them does.
+	anyDay     bool
+	anyWeekday bool
+}
+
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseCron reads a cron expression. It fails with storage.ErrValidation if
+// the expression cannot be read.
+func ParseCron(expr string) (Cron, error) {
+	expr = strings.TrimSpace(expr)
+	full := expr
+	if s, ok := cronShorthands[expr]; ok {
+		full = s
+	}
+	fields := strings.Fields(full)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("%w: cron expression %q needs five fields", storage.ErrValidation, expr)
+	}
+
+	c := Cron{
+		expr:       expr,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	var err error
+	for _, f := range []struct {
+		dst      *[]bool
+		field    string
+		min, max int
+	}{
+		{&c.minutes, fields[0], 0, 59},
+		{&c.hours, fields[1], 0, 23},
+		{&c.days, fields[2], 1, 31},
+		{&c.months, fields[3], 1, 12},
+		{&c.weekday, fields[4], 0, 7},
+	} {
+		if *f.dst, err = parseCronField(f.field, f.min, f.max); err != nil {
+			return Cron{}, fmt.Errorf("%w: cron expression %q: %v", storage.ErrValidation, expr, err)
+		}
+	}
+	if c.weekday[7] {
+		c.weekday[0] = true
+	}
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			var err error
+			if step, err = strconv.Atoi(after); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng = before
+		}
+		from, to := min, max
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(first); err != nil {
+				return nil, fmt.Errorf("invalid value in %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(last); err != nil {
+					return nil, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for i := from; i <= to; i += step {
+			set[i] = true
+		}
+	}
+
+	return set, nil
+}
+
+func (c Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first time after t that matches the expression, in the
+// location of t. It returns the zero time if there is none within five
+// years, like for the 31st of February.
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c Cron) matchDay(t time.Time) bool {
+	day, weekday := c.days[t.Day()], c.weekday[t.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
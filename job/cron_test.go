@@ -0,0 +1,220 @@
+package job
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"@daily",
+		"  @weekly ",
+		"0,30 9-17/2 1-15 */3 1-5",
+		"0 0 * * 7", // sunday as seven
+	} {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("%q: exp nil, got %v", expr, err)
+		}
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* * 0 * *",
+		"* 5-1 * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, storage.ErrValidation) {
+			t.Errorf("%q: exp %v, got %v", expr, storage.ErrValidation, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2025, time.January, 15, 10, 20, 30, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name string
+		expr string
+		from time.Time
+		exp  time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			from: from,
+			exp:  time.Date(2025, time.January, 15, 10, 21, 0, 0, time.UTC),
+		},
+		{
+			name: "after and not at",
+			expr: "* * * * *",
+			from: time.Date(2025, time.January, 15, 10, 21, 0, 0, time.UTC),
+			exp:  time.Date(2025, time.January, 15, 10, 22, 0, 0, time.UTC),
+		},
+		{
+			name: "hourly",
+			expr: "@hourly",
+			from: from,
+			exp:  time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "daily",
+			expr: "@daily",
+			from: from,
+			exp:  time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly",
+			expr: "@weekly",
+			from: from,
+			exp:  time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "monthly",
+			expr: "@monthly",
+			from: from,
+			exp:  time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+			expr: "0 4 1 1 *",
+			from: from,
+			exp:  time.Date(2026, time.January, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "step",
+			expr: "*/15 * * * *",
+			from: from,
+			exp:  time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as seven",
+			expr: "0 0 * * 7",
+			from: from,
+			exp:  time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day or weekday",
+			expr: "0 0 20 * 5",
+			from: from,
+			exp:  time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: from,
+			exp:  time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never",
+			expr: "0 0 31 2 *",
+			from: from,
+			exp:  time.Time{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if act := c.Next(tc.from); !act.Equal(tc.exp) {
+				t.Errorf("exp %v, got %v", tc.exp, act)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2025, time.January, 15, 10, 20, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		cron     string
+		timeZone string
+		exp      time.Time
+		expErr   error
+	}{
+		{
+			name:     "utc",
+			cron:     "0 3 * * *",
+			timeZone: "UTC",
+			exp:      time.Date(2025, time.January, 16, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "east of utc",
+			cron:     "0 3 * * *",
+			timeZone: "Europe/Amsterdam",
+			exp:      time.Date(2025, time.January, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "west of utc",
+			cron:     "0 3 * * *",
+			timeZone: "America/New_York",
+			exp:      time.Date(2025, time.January, 16, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "unknown time zone",
+			cron:     "0 3 * * *",
+			timeZone: "Nowhere/Else",
+			expErr:   storage.ErrValidation,
+		},
+		{
+			name:     "never",
+			cron:     "0 0 31 2 *",
+			timeZone: "UTC",
+			expErr:   storage.ErrValidation,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Schedule{Cron: tc.cron, TimeZone: tc.timeZone}
+			act, err := s.Next(from)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("exp %v, got %v", tc.expErr, err)
+			}
+			if !act.Equal(tc.exp) {
+				t.Errorf("exp %v, got %v", tc.exp, act)
+			}
+		})
+	}
+}
+
+func TestNewSchedule(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 10, 20, 0, 0, time.UTC)
+
+	s, err := NewSchedule("purge", "@daily", "", ActionPurgeTrash, "", now)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if s.TimeZone != DefaultTimeZone {
+		t.Errorf("exp %v, got %v", DefaultTimeZone, s.TimeZone)
+	}
+	if exp := time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC); !s.NextRun.Equal(exp) {
+		t.Errorf("exp %v, got %v", exp, s.NextRun)
+	}
+	if s, err = NewSchedule("purge", "@daily", "Europe/Amsterdam", ActionPurgeTrash, "", now); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if s.TimeZone != "Europe/Amsterdam" || !s.NextRun.After(now) {
+		t.Errorf("exp a run in Amsterdam after %v, got %v", now, s)
+	}
+
+	// no name, an unknown action and a cron expression that is not one
+	for _, args := range [][3]string{
+		{"", "@daily", ActionPurgeTrash},
+		{"purge", "@daily", "unknown"},
+		{"purge", "daily", ActionPurgeTrash},
+	} {
+		if _, err := NewSchedule(args[0], args[1], "", args[2], "", now); !errors.Is(err, storage.ErrValidation) {
+			t.Errorf("%v: exp %v, got %v", args, storage.ErrValidation, err)
+		}
+	}
+}
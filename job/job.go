@@ -1,7 +1,11 @@
 package job
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"slices"
+	"strings"
 	"time"
 )
 
@@ -12,10 +16,18 @@ const (
 	TypeSimple JobType = "simple"
 	TypeAI     JobType = "ai"
 
+	// Jobs with a higher priority go first. Jobs that someone asks for
+	// are high, the ones that the worker adds by itself are normal.
+	PriorityNormal = 0
+	PriorityHigh   = 10
+
 	ActionRefreshIMDBReviews    = "refresh-imdb-reviews"
 	ActionRefreshAllIMDBReviews = "refresh-all-imdb-reviews"
 	ActionFindTitles            = "find-titles"
 	ActionFindAllTitles         = "find-all-titles"
+	ActionRefreshTMDB           = "refresh-tmdb"
+	ActionRefreshAllTMDB        = "refresh-all-tmdb"
+	ActionPurgeTrash            = "purge-trash"
 )
 
 var (
@@ -23,21 +35,59 @@ var (
 		ActionRefreshIMDBReviews,
 		ActionRefreshAllIMDBReviews, // just creates a job for each movie
 		ActionFindAllTitles,         // just creates a job for each review
+		ActionRefreshTMDB,
+		ActionRefreshAllTMDB, // just creates a job for each movie
+		ActionPurgeTrash,
 	}
 	AIActions = []string{
 		ActionFindTitles,
 	}
 
 	ValidActions = append(SimpleActions, AIActions...)
+
+	// maxAttempts is how often a job with the action is tried before it
+	// fails for good. Jobs that ask Ollama are slow and get fewer tries.
+	maxAttempts = map[string]int{
+		ActionFindTitles:    3,
+		ActionFindAllTitles: 3,
+		ActionPurgeTrash:    3,
+	}
+)
+
+// errLeaseExpired is recorded with the jobs that Reap takes from a worker.
+var errLeaseExpired = errors.New("lease expired")
+
+const (
+	defaultMaxAttempts = 5
+	backoffBase        = 30 * time.Second
+	backoffMax         = 6 * time.Hour
 )
 
 type Job struct {
-	ID       int
-	ActionID string
-	Action   string
-	Status   JobStatus
-	Created  time.Time
-	Updated  time.Time
+	ID         int
+	ActionID   string
+	Action     string
+	Status     JobStatus
+	Worker     string    // the worker that claimed the job
+	LeaseUntil time.Time // the worker holds the job until then
+	Attempts   int       // how often the job was claimed
+	RunAt      time.Time // the job is not run before then
+	LastError  string    // why the last attempt went wrong
+	Priority   int
+	Created    time.Time
+	Updated    time.Time
+}
+
+// Actions returns the actions of the type.
+func Actions(t JobType) []string {
+	switch t {
+	case TypeSimple:
+		return SimpleActions
+	case TypeAI:
+		return AIActions
+	default:
+		return nil
+	}
 }
 
 func Valid(action string) bool {
@@ -47,3 +97,73 @@ func Valid(action string) bool {
 
 	return false
 }
+
+// MaxAttempts returns how often a job with the action is tried before it
+// fails for good.
+func MaxAttempts(action string) int {
+	if n, ok := maxAttempts[action]; ok {
+		return n
+	}
+
+	return defaultMaxAttempts
+}
+
+// maxAttemptsSQL is MaxAttempts as an SQL expression on the action column,
+// with the arguments it needs.
+func maxAttemptsSQL() (string, []any) {
+	actions := make([]string, 0, len(maxAttempts))
+	for action := range maxAttempts {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+
+	var b strings.Builder
+	args := make([]any, 0, len(actions))
+	b.WriteString("CASE action")
+	for _, action := range actions {
+		fmt.Fprintf(&b, " WHEN ? THEN %d", maxAttempts[action])
+		args = append(args, action)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", defaultMaxAttempts)
+
+	return b.String(), args
+}
+
+// Backoff returns how long to wait before the next try of a job that was
+// tried the given number of times. The wait doubles with every attempt.
+func Backoff(attempts int) time.Duration {
+	wait := backoffBase
+	for i := 1; i < attempts && wait < backoffMax; i++ {
+		wait *= 2
+	}
+
+	return min(wait, backoffMax)
+}
+
+// errorText is the error as it is stored with the job.
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// jobColumns are the columns that scanJob reads, in that order.
+const jobColumns = `id, action_id, action, status, worker, lease_until, attempts, run_at, last_error, priority, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (Job, error) {
+	var j Job
+	var leaseUntil, runAt sql.NullTime
+	if err := row.Scan(&j.ID, &j.ActionID, &j.Action, &j.Status, &j.Worker, &leaseUntil, &j.Attempts, &runAt, &j.LastError, &j.Priority, &j.Created, &j.Updated); err != nil {
+		return Job{}, err
+	}
+	j.LeaseUntil = leaseUntil.Time
+	j.RunAt = runAt.Time
+
+	return j, nil
+}
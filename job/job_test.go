@@ -0,0 +1,35 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	// attempts and the wait after them
+	for attempts, exp := range map[int]time.Duration{
+		0:    30 * time.Second,
+		1:    30 * time.Second,
+		2:    time.Minute,
+		5:    8 * time.Minute,
+		10:   256 * time.Minute,
+		11:   6 * time.Hour,
+		1000: 6 * time.Hour,
+	} {
+		if act := Backoff(attempts); act != exp {
+			t.Errorf("%d attempts: exp %v, got %v", attempts, exp, act)
+		}
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	if act := MaxAttempts(ActionRefreshTMDB); act != 5 {
+		t.Errorf("exp 5, got %d", act)
+	}
+	if act := MaxAttempts(ActionFindTitles); act != 3 {
+		t.Errorf("exp 3 for jobs that ask Ollama, got %d", act)
+	}
+	if act := MaxAttempts("unknown"); act != 5 {
+		t.Errorf("exp 5, got %d", act)
+	}
+}
@@ -0,0 +1,251 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+type MemoryJobQueue struct {
+	mu     sync.Mutex
+	lastID int
+	jobs   []Job
+	logger *slog.Logger
+}
+
+func NewMemoryJobQueue(logger *slog.Logger) *MemoryJobQueue {
+	return &MemoryJobQueue{
+		jobs:   make([]Job, 0),
+		logger: logger.With("service", "jobqueue"),
+	}
+}
+
+func (jq *MemoryJobQueue) Release(ctx context.Context, worker string) error {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	for i := range jq.jobs {
+		if jq.jobs[i].Status != "doing" || jq.jobs[i].Worker != worker {
+			continue
+		}
+		jq.jobs[i].Status = "todo"
+		jq.jobs[i].Worker = ""
+		jq.jobs[i].LeaseUntil = time.Time{}
+		jq.jobs[i].Updated = time.Now()
+	}
+
+	return nil
+}
+
+func (jq *MemoryJobQueue) Add(ctx context.Context, movieID, action string) error {
+	return jq.Enqueue(ctx, Job{ActionID: movieID, Action: action})
+}
+
+func (jq *MemoryJobQueue) Enqueue(ctx context.Context, j Job) error {
+	if !Valid(j.Action) {
+		return fmt.Errorf("%w: unknown action %s", storage.ErrValidation, j.Action)
+	}
+
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jq.lastID++
+	now := time.Now()
+	jq.jobs = append(jq.jobs, Job{
+		ID:       jq.lastID,
+		ActionID: j.ActionID,
+		Action:   j.Action,
+		Status:   "todo",
+		RunAt:    j.RunAt,
+		Priority: j.Priority,
+		Created:  now,
+		Updated:  now,
+	})
+
+	return nil
+}
+
+func (jq *MemoryJobQueue) Next(ctx context.Context, worker string, lease time.Duration, actions []string) (Job, error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	now := time.Now()
+	next := -1
+	for i, j := range jq.jobs {
+		if j.Status != "todo" || j.RunAt.After(now) || !slices.Contains(actions, j.Action) {
+			continue
+		}
+		// jobs are kept in the order they were added
+		if next == -1 || j.Priority > jq.jobs[next].Priority {
+			next = i
+		}
+	}
+	if next == -1 {
+		return Job{}, storage.ErrNotFound
+	}
+
+	jq.jobs[next].Status = "doing"
+	jq.jobs[next].Worker = worker
+	jq.jobs[next].LeaseUntil = now.Add(lease)
+	jq.jobs[next].Attempts++
+	jq.jobs[next].Updated = now
+	jq.logger.Info("claimed a job", "method", "next", "worker", worker, "id", jq.jobs[next].ID, "attempt", jq.jobs[next].Attempts)
+
+	return jq.jobs[next], nil
+}
+
+func (jq *MemoryJobQueue) Heartbeat(ctx context.Context, id int, worker string, lease time.Duration) error {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	for i := range jq.jobs {
+		if jq.jobs[i].ID != id || jq.jobs[i].Worker != worker || jq.jobs[i].Status != "doing" {
+			continue
+		}
+		jq.jobs[i].LeaseUntil = time.Now().Add(lease)
+		return nil
+	}
+
+	return fmt.Errorf("%w: job %d is not held by %s", storage.ErrNotFound, id, worker)
+}
+
+func (jq *MemoryJobQueue) Reap(ctx context.Context) (int, error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	var n int
+	now := time.Now()
+	for i := range jq.jobs {
+		if jq.jobs[i].Status != "doing" || !jq.jobs[i].LeaseUntil.Before(now) {
+			continue
+		}
+		if jq.jobs[i].Attempts >= MaxAttempts(jq.jobs[i].Action) {
+			jq.jobs[i].Status = "failed"
+		} else {
+			jq.jobs[i].Status = "todo"
+			jq.jobs[i].Worker = ""
+		}
+		jq.jobs[i].LeaseUntil = time.Time{}
+		jq.jobs[i].LastError = errorText(errLeaseExpired)
+		jq.jobs[i].Updated = now
+		n++
+	}
+
+	return n, nil
+}
+
+func (jq *MemoryJobQueue) MarkDone(ctx context.Context, id int, worker string) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if i := jq.held(id, worker); i >= 0 {
+		jq.remove(id)
+	}
+}
+
+func (jq *MemoryJobQueue) MarkFailed(ctx context.Context, id int, worker string, reason error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if i := jq.held(id, worker); i >= 0 {
+		jq.jobs[i].Status = "failed"
+		jq.jobs[i].LeaseUntil = time.Time{}
+		jq.jobs[i].LastError = errorText(reason)
+		jq.jobs[i].Updated = time.Now()
+	}
+}
+
+func (jq *MemoryJobQueue) Retry(ctx context.Context, id int, worker string, wait time.Duration, reason error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if i := jq.held(id, worker); i >= 0 {
+		jq.jobs[i].Status = "todo"
+		jq.jobs[i].Worker = ""
+		jq.jobs[i].LeaseUntil = time.Time{}
+		jq.jobs[i].RunAt = time.Now().Add(wait)
+		jq.jobs[i].LastError = errorText(reason)
+		jq.jobs[i].Updated = time.Now()
+	}
+}
+
+// held returns the index of the job if the worker is doing it, or -1.
+func (jq *MemoryJobQueue) held(id int, worker string) int {
+	for i := range jq.jobs {
+		if jq.jobs[i].ID == id && jq.jobs[i].Worker == worker && jq.jobs[i].Status == "doing" {
+			return i
+		}
+	}
+	jq.logger.Warn("job is no longer held by the worker, leaving it alone", "id", id, "worker", worker)
+
+	return -1
+}
+
+func (jq *MemoryJobQueue) Requeue(ctx context.Context, id int) error {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	for i := range jq.jobs {
+		if jq.jobs[i].ID != id || jq.jobs[i].Status != "failed" {
+			continue
+		}
+		jq.jobs[i].Status = "todo"
+		jq.jobs[i].Worker = ""
+		jq.jobs[i].Attempts = 0
+		jq.jobs[i].RunAt = time.Time{}
+		jq.jobs[i].Updated = time.Now()
+		return nil
+	}
+
+	return fmt.Errorf("%w: no failed job %d", storage.ErrNotFound, id)
+}
+
+func (jq *MemoryJobQueue) List(ctx context.Context) ([]Job, error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jobs := make([]Job, 0, len(jq.jobs))
+	for i := len(jq.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, jq.jobs[i])
+	}
+
+	return jobs, nil
+}
+
+func (jq *MemoryJobQueue) Delete(ctx context.Context, id string) error {
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jq.remove(jobID)
+
+	return nil
+}
+
+func (jq *MemoryJobQueue) DeleteAll(ctx context.Context) error {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jq.jobs = make([]Job, 0)
+
+	return nil
+}
+
+func (jq *MemoryJobQueue) remove(id int) {
+	for i := range jq.jobs {
+		if jq.jobs[i].ID == id {
+			jq.jobs = append(jq.jobs[:i], jq.jobs[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestMemoryJobQueueNext(t *testing.T) {
+	ctx := context.Background()
+	jq := NewMemoryJobQueue(newTestLogger())
+	actions := []string{ActionRefreshIMDBReviews, ActionFindAllTitles}
+
+	if _, err := jq.Next(ctx, "w", time.Minute, actions); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	for _, j := range []struct{ movieID, action string }{
+		{"first", ActionRefreshIMDBReviews},
+		{"ai", ActionFindTitles},
+		{"second", ActionFindAllTitles},
+		{"third", ActionRefreshIMDBReviews},
+	} {
+		if err := jq.Add(ctx, j.movieID, j.action); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	if err := jq.Add(ctx, "x", "unknown"); !errors.Is(err, storage.ErrValidation) {
+		t.Errorf("exp %v, got %v", storage.ErrValidation, err)
+	}
+
+	// jobs come out in the order they went in, skipping other actions
+	for _, exp := range []string{"first", "second", "third"} {
+		j, err := jq.Next(ctx, "w", time.Minute, actions)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if j.ActionID != exp {
+			t.Errorf("exp %s, got %s", exp, j.ActionID)
+		}
+		if j.Status != "doing" || j.Worker != "w" || j.Attempts != 1 {
+			t.Errorf("exp the job to be claimed by w, got %v", j)
+		}
+		jq.MarkDone(ctx, j.ID, "w")
+	}
+	if _, err := jq.Next(ctx, "w", time.Minute, actions); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+
+	jobs, err := jq.List(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Action != ActionFindTitles {
+		t.Errorf("exp only the find-titles job to be left, got %v", jobs)
+	}
+}
+
+func TestMemoryJobQueueRelease(t *testing.T) {
+	ctx := context.Background()
+	jq := NewMemoryJobQueue(newTestLogger())
+	actions := []string{ActionRefreshIMDBReviews}
+	for _, movieID := range []string{"mine", "theirs"} {
+		if err := jq.Add(ctx, movieID, ActionRefreshIMDBReviews); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	mine, err := jq.Next(ctx, "me", time.Minute, actions)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if _, err := jq.Next(ctx, "them", time.Minute, actions); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	if err := jq.Release(ctx, "me"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	j, err := jq.Next(ctx, "other", time.Minute, actions)
+	if err != nil {
+		t.Fatalf("exp the released job, got %v", err)
+	}
+	if j.ID != mine.ID {
+		t.Errorf("exp job %d, got %d", mine.ID, j.ID)
+	}
+	if _, err := jq.Next(ctx, "other", time.Minute, actions); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp the job of them to stay, got %v", err)
+	}
+}
+
+func TestMemoryJobQueuePriority(t *testing.T) {
+	ctx := context.Background()
+	jq := NewMemoryJobQueue(newTestLogger())
+	for _, j := range []Job{
+		{ActionID: "old", Action: ActionRefreshIMDBReviews},
+		{ActionID: "high", Action: ActionRefreshIMDBReviews, Priority: PriorityHigh},
+		{ActionID: "new", Action: ActionRefreshIMDBReviews},
+		{ActionID: "also high", Action: ActionRefreshIMDBReviews, Priority: PriorityHigh},
+	} {
+		if err := jq.Enqueue(ctx, j); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+
+	for _, exp := range []string{"high", "also high", "old", "new"} {
+		j, err := jq.Next(ctx, "w", time.Minute, []string{ActionRefreshIMDBReviews})
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if j.ActionID != exp {
+			t.Errorf("exp %s, got %s", exp, j.ActionID)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+type MemoryScheduleRepository struct {
+	mu        sync.Mutex
+	lastID    int
+	schedules map[string]Schedule
+}
+
+func NewMemoryScheduleRepository() *MemoryScheduleRepository {
+	return &MemoryScheduleRepository{
+		schedules: make(map[string]Schedule),
+	}
+}
+
+func (sr *MemoryScheduleRepository) Store(ctx context.Context, s Schedule) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if stored, ok := sr.schedules[s.Name]; ok {
+		s.ID, s.Created = stored.ID, stored.Created
+	} else {
+		sr.lastID++
+		s.ID, s.Created = sr.lastID, time.Now()
+	}
+	sr.schedules[s.Name] = s
+
+	return nil
+}
+
+func (sr *MemoryScheduleRepository) FindAll(ctx context.Context) ([]Schedule, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	schedules := make([]Schedule, 0, len(sr.schedules))
+	for _, s := range sr.schedules {
+		schedules = append(schedules, s)
+	}
+	slices.SortFunc(schedules, func(a, b Schedule) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return schedules, nil
+}
+
+func (sr *MemoryScheduleRepository) FindDue(ctx context.Context, now time.Time) ([]Schedule, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	schedules := make([]Schedule, 0)
+	for _, s := range sr.schedules {
+		if !s.NextRun.After(now) {
+			schedules = append(schedules, s)
+		}
+	}
+	slices.SortFunc(schedules, func(a, b Schedule) int {
+		return a.NextRun.Compare(b.NextRun)
+	})
+
+	return schedules, nil
+}
+
+func (sr *MemoryScheduleRepository) Advance(ctx context.Context, s Schedule, next time.Time) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	stored, ok := sr.schedules[s.Name]
+	if !ok || stored.ID != s.ID || !stored.NextRun.Equal(s.NextRun) {
+		return fmt.Errorf("%w: schedule %s was already advanced", storage.ErrConflict, s.Name)
+	}
+	stored.NextRun = next
+	sr.schedules[s.Name] = stored
+
+	return nil
+}
+
+func (sr *MemoryScheduleRepository) Delete(ctx context.Context, name string) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if _, ok := sr.schedules[name]; !ok {
+		return fmt.Errorf("%w: no schedule %s", storage.ErrNotFound, name)
+	}
+	delete(sr.schedules, name)
+
+	return nil
+}
@@ -1,129 +1,58 @@
 package job
 
 import (
-	"database/sql"
-	"errors"
+	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"go-mod.ewintr.nl/emdb/storage"
 )
 
-type JobQueue struct {
-	db     *storage.Postgres
-	logger *slog.Logger
-}
-
-func NewJobQueue(db *storage.Postgres, logger *slog.Logger) *JobQueue {
-	jq := &JobQueue{
-		db:     db,
-		logger: logger.With("service", "jobqueue"),
-	}
-
-	return jq
-}
-
-func (jq *JobQueue) ResetAll() error {
-	_, err := jq.db.Exec(`UPDATE job_queue SET status='todo'`)
-
-	return err
-}
-
-func (jq *JobQueue) Add(movieID, action string) error {
-	if !Valid(action) {
-		return errors.New("invalid action")
-	}
-
-	_, err := jq.db.Exec(`
-INSERT INTO job_queue (action_id, action, status) 
-VALUES ($1, $2, 'todo');`, movieID, action)
-
-	return err
-}
-
-func (jq *JobQueue) Next() (Job, error) {
-	logger := jq.logger.With("method", "next")
-
-	row := jq.db.QueryRow(`
-SELECT id, action_id, action
-FROM job_queue
-WHERE status='todo'
-ORDER BY id ASC
-LIMIT 1;`)
-	var job Job
-	err := row.Scan(&job.ID, &job.ActionID, &job.Action)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			logger.Error("could not fetch next job", "error", err)
-		}
-		return Job{}, err
-	}
-
-	logger.Info("found a job", "id", job.ID)
-	if _, err := jq.db.Exec(`
-UPDATE job_queue 
-SET status='doing'
-WHERE id=$1;`, job.ID); err != nil {
-		logger.Error("could not set job to doing", "error", err)
-		return Job{}, err
-	}
-
-	return job, nil
-}
-
-func (jq *JobQueue) MarkDone(id int) {
-	logger := jq.logger.With("method", "markdone")
-	if _, err := jq.db.Exec(`
-DELETE FROM job_queue
-WHERE id=$1;`, id); err != nil {
-		logger.Error("could not mark job done", "error", err)
-	}
-	return
-}
-
-func (jq *JobQueue) MarkFailed(id int) {
-	logger := jq.logger.With("method", "markfailed")
-	if _, err := jq.db.Exec(`
-UPDATE job_queue
-SET status='failed'
-WHERE id=$1;`, id); err != nil {
-		logger.Error("could not mark job failed", "error", err)
-	}
-	return
-}
-
-func (jq *JobQueue) List() ([]Job, error) {
-	rows, err := jq.db.Query(`
-SELECT id, action_id, action, status, created_at, updated_at
-FROM job_queue
-ORDER BY id DESC;`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var jobs []Job
-	for rows.Next() {
-		var j Job
-		if err := rows.Scan(&j.ID, &j.ActionID, &j.Action, &j.Status, &j.Created, &j.Updated); err != nil {
-			return nil, err
-		}
-		jobs = append(jobs, j)
-	}
-	return jobs, nil
-}
-
-func (jq *JobQueue) Delete(id string) error {
-	if _, err := jq.db.Exec(`
-DELETE FROM job_queue
-WHERE id=$1;`, id); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (jq *JobQueue) DeleteAll() error {
-	if _, err := jq.db.Exec(`DELETE FROM job_queue;`); err != nil {
-		return err
+type JobQueue interface {
+	// Release puts the jobs that the worker was still doing back in the
+	// queue. Jobs of other workers are left alone.
+	Release(ctx context.Context, worker string) error
+	Add(ctx context.Context, movieID, action string) error
+	// Enqueue adds a job with the action, action id, run time and priority
+	// of j. A job with a zero run time can run right away.
+	Enqueue(ctx context.Context, j Job) error
+	// Next claims the job with one of the actions that has the highest
+	// priority and is the oldest, among those that are still to do and not
+	// waiting for their run time. The worker holds it for the length of
+	// the lease. Every claim counts as an attempt. A job is only ever claimed by one worker,
+	// also when several of them ask at the same time.
+	Next(ctx context.Context, worker string, lease time.Duration, actions []string) (Job, error)
+	// Heartbeat extends the lease of the worker on the job. It fails with
+	// storage.ErrNotFound when the worker no longer holds the job.
+	Heartbeat(ctx context.Context, id int, worker string, lease time.Duration) error
+	// Reap puts the jobs with an expired lease back in the queue, or fails
+	// them when they have no attempts left, and returns how many there were.
+	Reap(ctx context.Context) (int, error)
+	// MarkDone, MarkFailed and Retry only change a job that the worker is
+	// still doing. A job that was reaped and claimed by another worker is
+	// left alone.
+	MarkDone(ctx context.Context, id int, worker string)
+	// MarkFailed gives up on the job and records why.
+	MarkFailed(ctx context.Context, id int, worker string, reason error)
+	// Retry puts the job back in the queue, to try it again when the wait
+	// is over, and records why the attempt went wrong.
+	Retry(ctx context.Context, id int, worker string, wait time.Duration, reason error)
+	// Requeue puts a failed job back in the queue with a fresh count of
+	// attempts.
+	Requeue(ctx context.Context, id int) error
+	List(ctx context.Context) ([]Job, error)
+	Delete(ctx context.Context, id string) error
+	DeleteAll(ctx context.Context) error
+}
+
+func NewJobQueue(backend storage.Backend, logger *slog.Logger) (JobQueue, error) {
+	switch db := backend.(type) {
+	case *storage.SQL:
+		return NewSQLJobQueue(db, logger), nil
+	case *storage.Memory:
+		return NewMemoryJobQueue(logger), nil
+	default:
+		return nil, fmt.Errorf("no job queue available for storage backend %T", backend)
 	}
-	return nil
 }
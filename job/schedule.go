@@ -0,0 +1,102 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+	// the time zones of schedules must load on hosts without a zone database
+	_ "time/tzdata"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+// DefaultTimeZone is the time zone of schedules that do not name one.
+const DefaultTimeZone = "UTC"
+
+// Schedule adds a job with the action every time the cron expression
+// matches. The expression is read in the time zone of the schedule, so all
+// workers agree on when it is due.
+type Schedule struct {
+	ID       int
+	Name     string
+	Cron     string
+	TimeZone string
+	Action   string
+	ActionID string
+	// NextRun is when the next job is due.
+	NextRun time.Time
+	Created time.Time
+}
+
+// NewSchedule checks the action, the cron expression and the time zone and
+// sets the first run after now. An empty time zone is DefaultTimeZone.
+func NewSchedule(name, cron, timeZone, action, actionID string, now time.Time) (Schedule, error) {
+	if name == "" {
+		return Schedule{}, fmt.Errorf("%w: a schedule needs a name", storage.ErrValidation)
+	}
+	if !Valid(action) {
+		return Schedule{}, fmt.Errorf("%w: unknown action %s", storage.ErrValidation, action)
+	}
+	if timeZone == "" {
+		timeZone = DefaultTimeZone
+	}
+	c, err := ParseCron(cron)
+	if err != nil {
+		return Schedule{}, err
+	}
+	s := Schedule{
+		Name:     name,
+		Cron:     c.String(),
+		TimeZone: timeZone,
+		Action:   action,
+		ActionID: actionID,
+	}
+	if s.NextRun, err = s.Next(now); err != nil {
+		return Schedule{}, err
+	}
+
+	return s, nil
+}
+
+// Next returns the first run after t, with the cron expression read in the
+// time zone of the schedule.
+func (s Schedule) Next(t time.Time) (time.Time, error) {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown time zone %q", storage.ErrValidation, s.TimeZone)
+	}
+	next := c.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: cron expression %q never matches", storage.ErrValidation, s.Cron)
+	}
+
+	return next, nil
+}
+
+type ScheduleRepository interface {
+	// Store adds the schedule, or replaces the one with the same name.
+	Store(ctx context.Context, s Schedule) error
+	FindAll(ctx context.Context) ([]Schedule, error)
+	// FindDue returns the schedules with a next run at or before now.
+	FindDue(ctx context.Context, now time.Time) ([]Schedule, error)
+	// Advance moves the next run of the schedule from s.NextRun to next.
+	// It fails with storage.ErrConflict when the run was moved already,
+	// by another worker.
+	Advance(ctx context.Context, s Schedule, next time.Time) error
+	Delete(ctx context.Context, name string) error
+}
+
+func NewScheduleRepository(backend storage.Backend) (ScheduleRepository, error) {
+	switch db := backend.(type) {
+	case *storage.SQL:
+		return NewSQLScheduleRepository(db), nil
+	case *storage.Memory:
+		return NewMemoryScheduleRepository(), nil
+	default:
+		return nil, fmt.Errorf("no schedules available for storage backend %T", backend)
+	}
+}
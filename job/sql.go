@@ -0,0 +1,228 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+type SQLJobQueue struct {
+	db     *storage.SQL
+	logger *slog.Logger
+}
+
+func NewSQLJobQueue(db *storage.SQL, logger *slog.Logger) *SQLJobQueue {
+	jq := &SQLJobQueue{
+		db:     db,
+		logger: logger.With("service", "jobqueue"),
+	}
+
+	return jq
+}
+
+func (jq *SQLJobQueue) Release(ctx context.Context, worker string) error {
+	if _, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET status='todo', worker='', lease_until=NULL
+WHERE status='doing' AND worker=?;`, worker); err != nil {
+		return jq.db.Error(err)
+	}
+
+	return nil
+}
+
+func (jq *SQLJobQueue) Add(ctx context.Context, movieID, action string) error {
+	return jq.Enqueue(ctx, Job{ActionID: movieID, Action: action})
+}
+
+func (jq *SQLJobQueue) Enqueue(ctx context.Context, j Job) error {
+	if !Valid(j.Action) {
+		return fmt.Errorf("%w: unknown action %s", storage.ErrValidation, j.Action)
+	}
+
+	_, err := jq.db.ExecContext(ctx, `
+INSERT INTO job_queue (action_id, action, status, run_at, priority)
+VALUES (?, ?, 'todo', ?, ?);`, j.ActionID, j.Action, sql.NullTime{Time: j.RunAt.UTC(), Valid: !j.RunAt.IsZero()}, j.Priority)
+
+	return jq.db.Error(err)
+}
+
+func (jq *SQLJobQueue) Next(ctx context.Context, worker string, lease time.Duration, actions []string) (Job, error) {
+	logger := jq.logger.With("method", "next", "worker", worker)
+
+	if len(actions) == 0 {
+		return Job{}, storage.ErrNotFound
+	}
+	// SQLite locks the whole database for a write, Postgres needs to be told
+	// to skip the rows that other workers are claiming
+	lock := ""
+	if jq.db.Type() == storage.TypePostgres {
+		lock = "\n\tFOR UPDATE SKIP LOCKED"
+	}
+	now := time.Now().UTC()
+	args := []any{worker, now.Add(lease), now}
+	for _, a := range actions {
+		args = append(args, a)
+	}
+	row := jq.db.QueryRowContext(ctx, `
+UPDATE job_queue
+SET status='doing', worker=?, lease_until=?, attempts=attempts+1, updated_at=CURRENT_TIMESTAMP
+WHERE id=(
+	SELECT id
+	FROM job_queue
+	WHERE status='todo' AND (run_at IS NULL OR run_at <= ?) AND action IN (?`+strings.Repeat(", ?", len(actions)-1)+`)
+	ORDER BY priority DESC, id ASC
+	LIMIT 1`+lock+`
+)
+RETURNING `+jobColumns+`;`, args...)
+	job, err := scanJob(row)
+	if err != nil {
+		err = jq.db.Error(err)
+		if !errors.Is(err, storage.ErrNotFound) {
+			logger.Error("could not claim next job", "error", err)
+		}
+		return Job{}, err
+	}
+
+	logger.Info("claimed a job", "id", job.ID, "attempt", job.Attempts)
+	return job, nil
+}
+
+func (jq *SQLJobQueue) Heartbeat(ctx context.Context, id int, worker string, lease time.Duration) error {
+	res, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET lease_until=?
+WHERE id=? AND worker=? AND status='doing';`, time.Now().UTC().Add(lease), id, worker)
+	if err != nil {
+		return jq.db.Error(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return jq.db.Error(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: job %d is not held by %s", storage.ErrNotFound, id, worker)
+	}
+
+	return nil
+}
+
+func (jq *SQLJobQueue) Reap(ctx context.Context) (int, error) {
+	maxExpr, maxArgs := maxAttemptsSQL()
+	args := append(append(append([]any{}, maxArgs...), maxArgs...), errorText(errLeaseExpired), time.Now().UTC())
+	res, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET status=CASE WHEN attempts >= `+maxExpr+` THEN 'failed' ELSE 'todo' END,
+  worker=CASE WHEN attempts >= `+maxExpr+` THEN worker ELSE '' END,
+  lease_until=NULL, last_error=?
+WHERE status='doing' AND lease_until < ?;`, args...)
+	if err != nil {
+		return 0, jq.db.Error(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, jq.db.Error(err)
+	}
+
+	return int(n), nil
+}
+
+func (jq *SQLJobQueue) MarkDone(ctx context.Context, id int, worker string) {
+	logger := jq.logger.With("method", "markdone", "worker", worker)
+	res, err := jq.db.ExecContext(ctx, `
+DELETE FROM job_queue
+WHERE id=? AND worker=? AND status='doing';`, id, worker)
+	jq.logHeld(logger, id, res, err, "could not mark job done")
+}
+
+func (jq *SQLJobQueue) MarkFailed(ctx context.Context, id int, worker string, reason error) {
+	logger := jq.logger.With("method", "markfailed", "worker", worker)
+	res, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET status='failed', lease_until=NULL, last_error=?
+WHERE id=? AND worker=? AND status='doing';`, errorText(reason), id, worker)
+	jq.logHeld(logger, id, res, err, "could not mark job failed")
+}
+
+func (jq *SQLJobQueue) Retry(ctx context.Context, id int, worker string, wait time.Duration, reason error) {
+	logger := jq.logger.With("method", "retry", "worker", worker)
+	res, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET status='todo', worker='', lease_until=NULL, run_at=?, last_error=?
+WHERE id=? AND worker=? AND status='doing';`, time.Now().UTC().Add(wait), errorText(reason), id, worker)
+	jq.logHeld(logger, id, res, err, "could not mark job todo")
+}
+
+// logHeld logs when an update of a job by its worker failed, or when the
+// worker no longer held the job and nothing was changed.
+func (jq *SQLJobQueue) logHeld(logger *slog.Logger, id int, res sql.Result, err error, msg string) {
+	if err != nil {
+		logger.Error(msg, "id", id, "error", jq.db.Error(err))
+		return
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		logger.Warn("job is no longer held by the worker, leaving it alone", "id", id)
+	}
+}
+
+func (jq *SQLJobQueue) Requeue(ctx context.Context, id int) error {
+	res, err := jq.db.ExecContext(ctx, `
+UPDATE job_queue
+SET status='todo', worker='', lease_until=NULL, attempts=0, run_at=NULL
+WHERE id=? AND status='failed';`, id)
+	if err != nil {
+		return jq.db.Error(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return jq.db.Error(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: no failed job %d", storage.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+func (jq *SQLJobQueue) List(ctx context.Context) ([]Job, error) {
+	rows, err := jq.db.QueryContext(ctx, `
+SELECT `+jobColumns+`
+FROM job_queue
+ORDER BY id DESC;`)
+	if err != nil {
+		return nil, jq.db.Error(err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, jq.db.Error(err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (jq *SQLJobQueue) Delete(ctx context.Context, id string) error {
+	if _, err := jq.db.ExecContext(ctx, `
+DELETE FROM job_queue
+WHERE id=?;`, id); err != nil {
+		return jq.db.Error(err)
+	}
+	return nil
+}
+
+func (jq *SQLJobQueue) DeleteAll(ctx context.Context) error {
+	if _, err := jq.db.ExecContext(ctx, `DELETE FROM job_queue;`); err != nil {
+		return jq.db.Error(err)
+	}
+	return nil
+}
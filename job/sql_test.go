@@ -0,0 +1,173 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+// newTestSQLJobQueue returns a queue in a new, migrated SQLite database.
+func newTestSQLJobQueue(t *testing.T) *SQLJobQueue {
+	t.Helper()
+	db, err := storage.NewSQLite(filepath.Join(t.TempDir(), "emdb.db"))
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	return NewSQLJobQueue(db, newTestLogger())
+}
+
+func TestSQLJobQueueNext(t *testing.T) {
+	ctx := context.Background()
+	jq := newTestSQLJobQueue(t)
+	actions := []string{ActionRefreshIMDBReviews, ActionFindAllTitles}
+
+	if _, err := jq.Next(ctx, "w", time.Minute, actions); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	for _, j := range []Job{
+		{ActionID: "old", Action: ActionRefreshIMDBReviews},
+		{ActionID: "ai", Action: ActionFindTitles, Priority: PriorityHigh},
+		{ActionID: "later", Action: ActionRefreshIMDBReviews, Priority: PriorityHigh, RunAt: time.Now().Add(time.Hour)},
+		{ActionID: "new", Action: ActionRefreshIMDBReviews},
+		{ActionID: "urgent", Action: ActionFindAllTitles, Priority: PriorityHigh},
+	} {
+		if err := jq.Enqueue(ctx, j); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+
+	// the highest priority first, then the oldest, and jobs that are not
+	// due or of another action are left alone
+	before := time.Now()
+	for _, exp := range []string{"urgent", "old", "new"} {
+		j, err := jq.Next(ctx, "w", time.Minute, actions)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if j.ActionID != exp {
+			t.Errorf("exp %s, got %s", exp, j.ActionID)
+		}
+		if j.Status != "doing" || j.Worker != "w" || j.Attempts != 1 {
+			t.Errorf("exp the job to be claimed by w, got %v", j)
+		}
+		if j.LeaseUntil.Before(before.Add(time.Minute - time.Second)) {
+			t.Errorf("exp a lease of a minute, got %v", j.LeaseUntil)
+		}
+	}
+	// a claimed job is not handed out twice
+	if _, err := jq.Next(ctx, "other", time.Minute, actions); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	if _, err := jq.Next(ctx, "w", time.Minute, nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v without actions, got %v", storage.ErrNotFound, err)
+	}
+
+	// released jobs can be claimed again and count another attempt
+	if err := jq.Release(ctx, "w"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	j, err := jq.Next(ctx, "other", time.Minute, actions)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if j.ActionID != "urgent" || j.Attempts != 2 {
+		t.Errorf("exp the second attempt of urgent, got %v", j)
+	}
+}
+
+func TestSQLJobQueueHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	jq := newTestSQLJobQueue(t)
+	if err := jq.Add(ctx, "ran", ActionRefreshIMDBReviews); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	j, err := jq.Next(ctx, "w", time.Second, []string{ActionRefreshIMDBReviews})
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	if err := jq.Heartbeat(ctx, j.ID, "w", time.Hour); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	jobs, err := jq.List(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].LeaseUntil.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("exp the lease to be extended by an hour, got %v", jobs)
+	}
+
+	// only the worker that holds the job can extend its lease
+	if err := jq.Heartbeat(ctx, j.ID, "other", time.Hour); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	if err := jq.Release(ctx, "w"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := jq.Heartbeat(ctx, j.ID, "w", time.Hour); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v after the job was released, got %v", storage.ErrNotFound, err)
+	}
+}
+
+func TestSQLJobQueueReap(t *testing.T) {
+	ctx := context.Background()
+	jq := newTestSQLJobQueue(t)
+	for _, j := range []struct{ movieID, action string }{
+		{"expired", ActionRefreshIMDBReviews},
+		{"exhausted", ActionFindTitles},
+		{"alive", ActionRefreshIMDBReviews},
+	} {
+		if err := jq.Add(ctx, j.movieID, j.action); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	claimed := make(map[string]Job)
+	for _, c := range []struct {
+		lease  time.Duration
+		action string
+	}{
+		{-time.Minute, ActionRefreshIMDBReviews},
+		{-time.Minute, ActionFindTitles},
+		{time.Hour, ActionRefreshIMDBReviews},
+	} {
+		j, err := jq.Next(ctx, "w", c.lease, []string{c.action})
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		claimed[j.ActionID] = j
+	}
+	// the find-titles job has used its last attempt
+	if _, err := jq.db.ExecContext(ctx, `UPDATE job_queue SET attempts=? WHERE id=?`, MaxAttempts(ActionFindTitles), claimed["exhausted"].ID); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	n, err := jq.Reap(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exp 2 jobs, got %d", n)
+	}
+	jobs, err := jq.List(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	byMovie := make(map[string]Job)
+	for _, j := range jobs {
+		byMovie[j.ActionID] = j
+	}
+	if j := byMovie["expired"]; j.Status != "todo" || j.Worker != "" || j.LastError != errLeaseExpired.Error() {
+		t.Errorf("exp the expired job back in the queue, got %v", j)
+	}
+	if j := byMovie["exhausted"]; j.Status != "failed" || j.LastError != errLeaseExpired.Error() {
+		t.Errorf("exp the exhausted job to fail, got %v", j)
+	}
+	if j := byMovie["alive"]; j.Status != "doing" || j.Worker != "w" {
+		t.Errorf("exp the job with a lease to stay, got %v", j)
+	}
+}
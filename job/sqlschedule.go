@@ -0,0 +1,103 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+type SQLScheduleRepository struct {
+	db *storage.SQL
+}
+
+func NewSQLScheduleRepository(db *storage.SQL) *SQLScheduleRepository {
+	return &SQLScheduleRepository{
+		db: db,
+	}
+}
+
+func (sr *SQLScheduleRepository) Store(ctx context.Context, s Schedule) error {
+	if _, err := sr.db.ExecContext(ctx, `
+INSERT INTO job_schedule (name, cron, timezone, action, action_id, next_run)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (name) DO UPDATE SET cron = excluded.cron, timezone = excluded.timezone, action = excluded.action,
+action_id = excluded.action_id, next_run = excluded.next_run;`,
+		s.Name, s.Cron, s.TimeZone, s.Action, s.ActionID, s.NextRun.UTC()); err != nil {
+		return sr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (sr *SQLScheduleRepository) FindAll(ctx context.Context) ([]Schedule, error) {
+	return sr.query(ctx, `
+SELECT id, name, cron, timezone, action, action_id, next_run, created_at
+FROM job_schedule
+ORDER BY name;`)
+}
+
+func (sr *SQLScheduleRepository) FindDue(ctx context.Context, now time.Time) ([]Schedule, error) {
+	return sr.query(ctx, `
+SELECT id, name, cron, timezone, action, action_id, next_run, created_at
+FROM job_schedule
+WHERE next_run <= ?
+ORDER BY next_run;`, now.UTC())
+}
+
+func (sr *SQLScheduleRepository) Advance(ctx context.Context, s Schedule, next time.Time) error {
+	res, err := sr.db.ExecContext(ctx, `
+UPDATE job_schedule
+SET next_run=?
+WHERE id=? AND next_run=?;`, next.UTC(), s.ID, s.NextRun.UTC())
+	if err != nil {
+		return sr.db.Error(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return sr.db.Error(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: schedule %s was already advanced", storage.ErrConflict, s.Name)
+	}
+
+	return nil
+}
+
+func (sr *SQLScheduleRepository) Delete(ctx context.Context, name string) error {
+	res, err := sr.db.ExecContext(ctx, `
+DELETE FROM job_schedule
+WHERE name=?;`, name)
+	if err != nil {
+		return sr.db.Error(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return sr.db.Error(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: no schedule %s", storage.ErrNotFound, name)
+	}
+
+	return nil
+}
+
+func (sr *SQLScheduleRepository) query(ctx context.Context, query string, args ...any) ([]Schedule, error) {
+	rows, err := sr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, sr.db.Error(err)
+	}
+	defer rows.Close()
+
+	schedules := make([]Schedule, 0)
+	for rows.Next() {
+		var s Schedule
+		if err := rows.Scan(&s.ID, &s.Name, &s.Cron, &s.TimeZone, &s.Action, &s.ActionID, &s.NextRun, &s.Created); err != nil {
+			return nil, sr.db.Error(err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
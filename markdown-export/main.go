@@ -1,105 +1,354 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
 
+	"go-mod.ewintr.nl/emdb/client"
+	"go-mod.ewintr.nl/emdb/imagecache"
 	"go-mod.ewintr.nl/emdb/storage"
 	"go-mod.ewintr.nl/go-kit/slugify"
 )
 
 const (
 	pageTemplate = `+++
-title = "{{ .Title }}"
+title = {{ toml .Title }}
 date = {{ .Date }}
 draft = false
 extra.movie.year = {{ .Year }}
-extra.movie.directors = "{{ .Directors }}"
-extra.movie.en_title = "{{ .EnTitle }}"
+extra.movie.directors = {{ toml .Directors }}
+extra.movie.writers = {{ toml .Writers }}
+extra.movie.cinematographers = {{ toml .Cinematographers }}
+extra.movie.composers = {{ toml .Composers }}
+extra.movie.editors = {{ toml .Editors }}
+extra.movie.cast = [{{ range $i, $c := .Cast }}{{ if $i }}, {{ end }}{ name = {{ toml $c.Person.Name }}, character = {{ toml $c.Character }} }{{ end }}]
+extra.movie.en_title = {{ toml .EnTitle }}
 extra.movie.rating = {{ .Rating }}
-+++
+extra.movie.tags = [{{ range $i, $t := .Tags }}{{ if $i }}, {{ end }}{{ toml $t }}{{ end }}]
+{{ if .Poster }}extra.movie.poster = {{ toml .Poster }}
+{{ end }}+++
 
 {{ .Comment }}<!-- more -->`
+	listTemplate = `+++
+title = {{ toml .Name }}
+draft = false
+extra.list.description = {{ toml .Description }}
++++
+
+{{ .Description }}
+
+{{ range .Entries }}1. {{ if .Link }}[{{ .Title }}]({{ .Link }}){{ else }}{{ .Title }}{{ end }} ({{ .Year }})
+{{ end }}`
+
+	moviePageSize = 200
 )
 
+// templateFuncs are the functions the templates can use. toml quotes a
+// value for the front matter.
+var templateFuncs = template.FuncMap{
+	"toml": tomlString,
+}
+
+type page struct {
+	Title            string
+	Date             storage.Date
+	Year             int
+	Directors        string
+	Writers          string
+	Cinematographers string
+	Composers        string
+	Editors          string
+	Cast             []storage.Credit
+	EnTitle          string
+	Rating           string
+	Comment          string
+	Tags             []string
+	// Poster is the file name of the poster, next to the page.
+	Poster string
+}
+
+type listPage struct {
+	Name        string
+	Description string
+	Entries     []listEntry
+}
+
+type listEntry struct {
+	Title string
+	Year  int
+	Link  string
+}
+
+// tomlString quotes s as a basic TOML string. Quotes and backslashes are
+// escaped, and control characters are written as \uXXXX.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func newPage(m storage.Movie) page {
+	enTitle := m.EnglishTitle
+	if enTitle == m.Title {
+		enTitle = ""
+	}
+
+	return page{
+		Title:            m.Title,
+		Date:             m.WatchedOn,
+		Year:             m.Year,
+		Directors:        strings.Join(storage.PersonNames(m.Directors), ", "),
+		Writers:          strings.Join(storage.PersonNames(m.People(storage.RoleWriter)), ", "),
+		Cinematographers: strings.Join(storage.PersonNames(m.People(storage.RoleCinematographer)), ", "),
+		Composers:        strings.Join(storage.PersonNames(m.People(storage.RoleComposer)), ", "),
+		Editors:          strings.Join(storage.PersonNames(m.People(storage.RoleEditor)), ", "),
+		Cast:             m.Cast,
+		EnTitle:          enTitle,
+		Rating:           fmt.Sprintf("%d", m.Rating),
+		Comment:          m.Comment,
+		Tags:             m.Tags,
+	}
+}
+
 func main() {
-	dbHost := os.Getenv("EMDB_DB_HOST")
-	dbName := os.Getenv("EMDB_DB_NAME")
-	dbUser := os.Getenv("EMDB_DB_USER")
-	dbPassword := os.Getenv("EMDB_DB_PASSWORD")
-	pgConnStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPassword, dbName)
-	dbPostgres, err := storage.NewPostgres(pgConnStr)
+	perViewing := flag.Bool("per-viewing", false, "write a page for every viewing instead of one per movie")
+	flag.Parse()
+
+	db, err := storage.Open(storage.ConfigFromEnv())
+	if err != nil {
+		fmt.Printf("could not open database: %s", err.Error())
+		os.Exit(1)
+	}
+	ctx := context.Background()
+	movieRepo := db.Movies()
+	movies, err := storage.QueryAll(ctx, movieRepo, storage.MovieQuery{
+		Statuses: []storage.Status{storage.StatusWatched},
+		Sort:     []storage.Sort{{Field: storage.SortWatchedOn}},
+	}, moviePageSize)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	tpl, err := template.New("page").Funcs(templateFuncs).Parse(pageTemplate)
 	if err != nil {
-		fmt.Printf("could not create new postgres repo: %s", err.Error())
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	movieRepo := storage.NewMovieRepository(dbPostgres)
-	movies, err := movieRepo.FindAll()
+	listTpl, err := template.New("list").Funcs(templateFuncs).Parse(listTemplate)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	tpl, err := template.New("page").Parse(pageTemplate)
+	imageDir, err := imagecache.DirFromEnv()
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	images := imagecache.New(imageDir, db.Images(), client.NewImages())
 
 	path := "public"
 	Empty(path)
 
+	var links map[string]string
+	if *perViewing {
+		links, err = writeViewingPages(ctx, tpl, path, movies, db.Viewings(), images)
+	} else {
+		links, err = writeMoviePages(ctx, tpl, path, movies, images)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := writeListPages(ctx, listTpl, path, movieRepo, db.Lists(), links); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// writeMoviePages writes a page for every watched movie and returns the
+// internal links to them.
+func writeMoviePages(ctx context.Context, tpl *template.Template, path string, movies []storage.Movie, images *imagecache.Cache) (map[string]string, error) {
+	links := make(map[string]string)
 	for _, m := range movies {
-		filename := fmt.Sprintf("%s.md", slugify.Slugify(m.EnglishTitle))
+		if m.WatchedOn.IsZero() {
+			fmt.Printf("skipping %s, it has no watch date\n", m.Title)
+			continue
+		}
+		name := slugify.Slugify(m.EnglishTitle)
+		filename := fmt.Sprintf("%s.md", name)
+		p := newPage(m)
+		p.Poster = copyPoster(ctx, images, m, fmt.Sprintf("%s/%d", path, m.WatchedOn.Year()), name)
+		if err := writePage(tpl, path, filename, p); err != nil {
+			return nil, err
+		}
+		links[m.ID] = fmt.Sprintf("@/%d/%s", m.WatchedOn.Year(), filename)
+	}
+
+	return links, nil
+}
 
-		watchedOn := strings.Split(m.WatchedOn, "-")
-		watchedOnYear := watchedOn[0]
+// writeViewingPages writes a page for every viewing of the movies and
+// returns the internal links to the most recent viewing of each.
+func writeViewingPages(ctx context.Context, tpl *template.Template, path string, movies []storage.Movie, viewingRepo storage.ViewingRepository, images *imagecache.Cache) (map[string]string, error) {
+	viewings, err := viewingRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]storage.Movie, len(movies))
+	for _, m := range movies {
+		index[m.ID] = m
+	}
 
-		if err := os.MkdirAll(fmt.Sprintf("%s/%s", path, watchedOnYear), os.ModePerm); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+	links := make(map[string]string)
+	for _, v := range viewings {
+		m, ok := index[v.MovieID]
+		if !ok || v.WatchedOn.IsZero() {
+			continue
 		}
-		filePath := fmt.Sprintf("%s/%s/%s", path, watchedOnYear, filename)
-		f, err := os.Create(filePath)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		p := newPage(m)
+		p.Date = v.WatchedOn
+		p.Rating = fmt.Sprintf("%d", v.Rating)
+		if v.Notes != "" {
+			p.Comment = v.Notes
 		}
+		name := fmt.Sprintf("%s-%s", slugify.Slugify(m.EnglishTitle), v.WatchedOn)
+		filename := fmt.Sprintf("%s.md", name)
+		p.Poster = copyPoster(ctx, images, m, fmt.Sprintf("%s/%d", path, v.WatchedOn.Year()), name)
+		if err := writePage(tpl, path, filename, p); err != nil {
+			return nil, err
+		}
+		if _, ok := links[m.ID]; !ok {
+			links[m.ID] = fmt.Sprintf("@/%d/%s", v.WatchedOn.Year(), filename)
+		}
+	}
+
+	return links, nil
+}
+
+// writeListPages writes a page for every list in the lists directory. Movies
+// with a page of their own are linked.
+func writeListPages(ctx context.Context, tpl *template.Template, path string, movieRepo storage.MovieRepository, listRepo storage.ListRepository, links map[string]string) error {
+	lists, err := listRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+	movies, err := storage.QueryAll(ctx, movieRepo, storage.MovieQuery{}, moviePageSize)
+	if err != nil {
+		return err
+	}
+	index := make(map[string]storage.Movie, len(movies))
+	for _, m := range movies {
+		index[m.ID] = m
+	}
 
-		enTitle := m.EnglishTitle
-		if enTitle == m.Title {
-			enTitle = ""
+	if err := os.MkdirAll(fmt.Sprintf("%s/lists", path), os.ModePerm); err != nil {
+		return err
+	}
+	for _, l := range lists {
+		p := listPage{
+			Name:        l.Name,
+			Description: l.Description,
+			Entries:     make([]listEntry, 0, len(l.MovieIDs)),
+		}
+		for _, id := range l.MovieIDs {
+			m, ok := index[id]
+			if !ok {
+				continue
+			}
+			p.Entries = append(p.Entries, listEntry{
+				Title: m.EnglishTitle,
+				Year:  m.Year,
+				Link:  links[id],
+			})
 		}
 
-		data := struct {
-			Title     string
-			Date      string
-			Year      int
-			Directors string
-			EnTitle   string
-			Rating    string
-			Comment   string
-		}{
-			Title:     m.Title,
-			Date:      m.WatchedOn,
-			Year:      m.Year,
-			Directors: strings.Join(m.Directors, ", "),
-			EnTitle:   enTitle,
-			Rating:    fmt.Sprintf("%d", m.Rating),
-			Comment:   m.Comment,
+		f, err := os.Create(fmt.Sprintf("%s/lists/%s.md", path, slugify.Slugify(l.Name)))
+		if err != nil {
+			return err
 		}
-		if err := tpl.Execute(f, data); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		if err := tpl.Execute(f, p); err != nil {
+			f.Close()
+			return err
 		}
 		if err := f.Close(); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
 		}
 	}
+
+	return nil
+}
+
+// copyPoster copies the poster of the movie from the image cache to the
+// directory and returns the file name. A movie without a poster, or one that
+// cannot be downloaded, just gets no poster.
+func copyPoster(ctx context.Context, images *imagecache.Cache, m storage.Movie, dir, name string) string {
+	src, err := images.Poster(ctx, m)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return ""
+	case err != nil:
+		fmt.Printf("could not get poster of %s: %s\n", m.Title, err)
+		return ""
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		fmt.Printf("could not read poster of %s: %s\n", m.Title, err)
+		return ""
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		fmt.Printf("could not copy poster of %s: %s\n", m.Title, err)
+		return ""
+	}
+	filename := name + filepath.Ext(src)
+	if err := os.WriteFile(filepath.Join(dir, filename), data, 0644); err != nil {
+		fmt.Printf("could not copy poster of %s: %s\n", m.Title, err)
+		return ""
+	}
+
+	return filename
+}
+
+// writePage writes the page in a directory named after the year it was watched.
+func writePage(tpl *template.Template, path, filename string, p page) error {
+	watchedOnYear := p.Date.Year()
+	if err := os.MkdirAll(fmt.Sprintf("%s/%d", path, watchedOnYear), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(fmt.Sprintf("%s/%d/%s", path, watchedOnYear, filename))
+	if err != nil {
+		return err
+	}
+	if err := tpl.Execute(f, p); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
 }
 
 func Empty(dir string) error {
@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/user"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditTableMovie  = "movie"
+	AuditTableReview = "review"
+)
+
+var (
+	ErrUnknownRevision = fmt.Errorf("revision %w", ErrNotFound)
+)
+
+// FieldChange is the change of one field. The values are JSON encoded, an
+// empty string means the record did not exist.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Revision is one change to one record: everything that was changed by a
+// single Store or Delete.
+type Revision struct {
+	ID        string        `json:"id"`
+	Table     string        `json:"table"`
+	RowID     string        `json:"rowID"`
+	Actor     string        `json:"actor"`
+	ChangedAt time.Time     `json:"changedAt"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+type AuditRepository interface {
+	// FindByRow returns the revisions of a record, the most recent first.
+	FindByRow(ctx context.Context, table, rowID string) ([]Revision, error)
+	// FindByActor returns the revisions of the table that were made by the
+	// actor, the most recent first.
+	FindByActor(ctx context.Context, table, actor string) ([]Revision, error)
+}
+
+// NewerThan returns the revisions that come before the one with the given
+// id, in a list with the most recent first. These are all of them when the
+// id is empty or not in the list.
+func NewerThan(revisions []Revision, revisionID string) []Revision {
+	for i, rev := range revisions {
+		if rev.ID == revisionID {
+			return revisions[:i]
+		}
+	}
+
+	return revisions
+}
+
+// defaultActor is the name that is recorded with changes when no actor
+// was configured.
+func defaultActor() string {
+	u, err := user.Current()
+	if err != nil {
+		return "unknown"
+	}
+
+	return u.Username
+}
+
+// ActorTMDB is recorded with the changes of a refresh from TMDB.
+const ActorTMDB = "tmdb"
+
+type actorKey struct{}
+
+// WithActor returns a context that records the changes that are made with
+// it under the name of actor, instead of the configured one.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorOf returns the actor of the context, or the configured one.
+func actorOf(ctx context.Context, configured string) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+
+	return configured
+}
+
+// newRevision compares the JSON representations of the old and the new
+// version of a record. Pass nil for a record that is created or deleted.
+func newRevision(table, rowID, actor string, old, new any) (Revision, error) {
+	oldFields, err := jsonFields(old)
+	if err != nil {
+		return Revision{}, err
+	}
+	newFields, err := jsonFields(new)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	rev := Revision{
+		ID:      uuid.New().String(),
+		Table:   table,
+		RowID:   rowID,
+		Actor:   actor,
+		Changes: make([]FieldChange, 0),
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, "id") || strings.EqualFold(name, "version") || oldFields[name] == newFields[name] {
+			continue
+		}
+		rev.Changes = append(rev.Changes, FieldChange{
+			Field: name,
+			Old:   oldFields[name],
+			New:   newFields[name],
+		})
+	}
+
+	return rev, nil
+}
+
+// Diff returns the fields that differ between two versions of a record, in
+// the same form as they are recorded in the audit log.
+func Diff(old, new any) ([]FieldChange, error) {
+	rev, err := newRevision("", "", "", old, new)
+	if err != nil {
+		return nil, err
+	}
+
+	return rev.Changes, nil
+}
+
+func jsonFields(v any) (map[string]string, error) {
+	fields := make(map[string]string)
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for name, value := range raw {
+		fields[name] = string(value)
+	}
+
+	return fields, nil
+}
+
+// insertRevision stores the field changes of the revision with the insert
+// query of the dialect.
+func insertRevision(ctx context.Context, tx querier, query string, rev Revision) error {
+	for _, c := range rev.Changes {
+		if _, err := tx.ExecContext(ctx, query, rev.ID, rev.Table, rev.RowID, c.Field, c.Old, c.New, rev.Actor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanRevisions groups audit rows, ordered by revision, into revisions.
+func scanRevisions(rows *sql.Rows) ([]Revision, error) {
+	revisions := make([]Revision, 0)
+	for rows.Next() {
+		var rev Revision
+		var c FieldChange
+		if err := rows.Scan(&rev.ID, &rev.Table, &rev.RowID, &rev.Actor, &rev.ChangedAt, &c.Field, &c.Old, &c.New); err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 || revisions[len(revisions)-1].ID != rev.ID {
+			rev.Changes = make([]FieldChange, 0)
+			revisions = append(revisions, rev)
+		}
+		last := &revisions[len(revisions)-1]
+		last.Changes = append(last.Changes, c)
+	}
+	for _, rev := range revisions {
+		slices.SortFunc(rev.Changes, func(a, b FieldChange) int {
+			return strings.Compare(a.Field, b.Field)
+		})
+	}
+
+	return revisions, rows.Err()
+}
+
+// RestoreMovie stores the movie as it was right after the revision. All
+// later changes are undone, which is recorded as a new revision. This also
+// moves the movie in or out of the trash, and a movie that was removed
+// permanently can be restored this way too. It all happens in one unit of
+// work, so the movie is never stored without going in or out of the trash.
+func RestoreMovie(ctx context.Context, db Backend, movieID, revisionID string) (Movie, error) {
+	movieRepo := db.Movies()
+	var restored Movie
+	if err := db.InTx(ctx, func(ctx context.Context) error {
+		current, err := movieRepo.FindOne(ctx, movieID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			current = Movie{}
+		case err != nil:
+			return err
+		}
+
+		revisions, err := db.Audit().FindByRow(ctx, AuditTableMovie, movieID)
+		if err != nil {
+			return err
+		}
+
+		if err := restore(current, revisions, revisionID, &restored); err != nil {
+			return err
+		}
+		restored.ID = movieID
+		restored.Version = current.Version
+		if err := movieRepo.Store(ctx, restored); err != nil {
+			return err
+		}
+		// Store leaves the trash alone
+		switch {
+		case restored.DeletedAt.IsZero() && !current.DeletedAt.IsZero():
+			return movieRepo.Restore(ctx, movieID)
+		case !restored.DeletedAt.IsZero() && current.DeletedAt.IsZero():
+			return movieRepo.Delete(ctx, movieID)
+		}
+		return nil
+	}); err != nil {
+		return Movie{}, err
+	}
+
+	return movieRepo.FindOne(ctx, movieID)
+}
+
+// RestoreReview stores the review as it was right after the revision, like
+// RestoreMovie. A review that was removed with its movie can only come back
+// when the movie is there.
+func RestoreReview(ctx context.Context, reviewRepo ReviewRepository, auditRepo AuditRepository, reviewID, revisionID string) (Review, error) {
+	current, err := reviewRepo.FindOne(ctx, reviewID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		current = Review{}
+	case err != nil:
+		return Review{}, err
+	}
+
+	revisions, err := auditRepo.FindByRow(ctx, AuditTableReview, reviewID)
+	if err != nil {
+		return Review{}, err
+	}
+
+	var restored Review
+	if err := restore(current, revisions, revisionID, &restored); err != nil {
+		return Review{}, err
+	}
+	restored.ID = reviewID
+	restored.Version = current.Version
+	if err := reviewRepo.Store(ctx, restored); err != nil {
+		return Review{}, err
+	}
+
+	return reviewRepo.FindOne(ctx, reviewID)
+}
+
+// restore undoes the revisions that are newer than the one with revisionID
+// and decodes the result in dst.
+func restore(current any, revisions []Revision, revisionID string, dst any) error {
+	fields, err := jsonFields(current)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, rev := range revisions {
+		if rev.ID == revisionID {
+			found = true
+			break
+		}
+		for _, c := range rev.Changes {
+			if c.Old == "" {
+				delete(fields, c.Field)
+				continue
+			}
+			fields[c.Field] = c.Old
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownRevision, revisionID)
+	}
+
+	raw := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		raw[name] = json.RawMessage(value)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dst)
+}
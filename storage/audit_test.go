@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type auditRecord struct {
+	Title  string   `json:"title"`
+	Rating int      `json:"rating"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func TestRestore(t *testing.T) {
+	versions := []any{
+		nil,
+		auditRecord{Title: "Ran", Rating: 8},
+		auditRecord{Title: "Ran", Rating: 9},
+		auditRecord{Title: "Ran", Rating: 9, Tags: []string{"epic"}},
+		auditRecord{Title: "Ran (1985)", Rating: 10, Tags: []string{"epic", "war"}},
+		nil,
+	}
+	// the most recent first, like FindByRow returns them
+	revisions := make([]Revision, 0)
+	for i := 1; i < len(versions); i++ {
+		rev, err := newRevision(AuditTableMovie, "id", "test", versions[i-1], versions[i])
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		revisions = append([]Revision{rev}, revisions...)
+	}
+	last := versions[len(versions)-2].(auditRecord)
+
+	for _, tc := range []struct {
+		name       string
+		current    any
+		revisionID string
+		exp        auditRecord
+		expErr     error
+	}{
+		{
+			name:       "latest",
+			current:    last,
+			revisionID: revisions[1].ID,
+			exp:        last,
+		},
+		{
+			name:       "one back",
+			current:    last,
+			revisionID: revisions[2].ID,
+			exp:        auditRecord{Title: "Ran", Rating: 9, Tags: []string{"epic"}},
+		},
+		{
+			name:       "before a field was set",
+			current:    last,
+			revisionID: revisions[3].ID,
+			exp:        auditRecord{Title: "Ran", Rating: 9},
+		},
+		{
+			name:       "created",
+			current:    last,
+			revisionID: revisions[4].ID,
+			exp:        auditRecord{Title: "Ran", Rating: 8},
+		},
+		{
+			name:       "removed",
+			current:    nil,
+			revisionID: revisions[1].ID,
+			exp:        last,
+		},
+		{
+			name:       "removed and further back",
+			current:    nil,
+			revisionID: revisions[3].ID,
+			exp:        auditRecord{Title: "Ran", Rating: 9},
+		},
+		{
+			name:       "unknown revision",
+			current:    last,
+			revisionID: "unknown",
+			expErr:     ErrUnknownRevision,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var act auditRecord
+			err := restore(tc.current, revisions, tc.revisionID, &act)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("exp %v, got %v", tc.expErr, err)
+			}
+			if tc.expErr != nil {
+				return
+			}
+			changes, err := Diff(tc.exp, act)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(changes) != 0 {
+				t.Errorf("exp %v, got %v", tc.exp, act)
+			}
+		})
+	}
+}
+
+func TestRestoreMovie(t *testing.T) {
+	ctx := testContext(t)
+	mem := NewMemory()
+	movies, audit := mem.Movies(), mem.Audit()
+
+	m := Movie{ID: "ran", Title: "Ran", Rating: 8, Status: StatusWatched, WatchedOn: NewDate(2020, time.May, 1)}
+	if err := movies.Store(ctx, m); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := UpdateMovie(ctx, movies, m, func(m *Movie) { m.Rating = 3 }); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := movies.Delete(ctx, "ran"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	revisions, err := audit.FindByRow(ctx, AuditTableMovie, "ran")
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("exp 3 revisions, got %d", len(revisions))
+	}
+
+	check := func(revision Revision, expRating int, expTrash bool) {
+		t.Helper()
+		act, err := RestoreMovie(ctx, mem, "ran", revision.ID)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if act.Rating != expRating {
+			t.Errorf("exp %d, got %d", expRating, act.Rating)
+		}
+		if act.DeletedAt.IsZero() == expTrash {
+			t.Errorf("exp in the trash to be %v, got %v", expTrash, act.DeletedAt)
+		}
+		if act.WatchedOn != m.WatchedOn {
+			t.Errorf("exp %v, got %v", m.WatchedOn, act.WatchedOn)
+		}
+	}
+
+	// out of the trash, then back to the first rating, then in the trash again
+	check(revisions[1], 3, false)
+	check(revisions[2], 8, false)
+	check(revisions[0], 3, true)
+}
+
+func TestDeleteReviewsByMovieID(t *testing.T) {
+	for name, b := range map[string]Backend{
+		"memory": NewMemory(),
+		"sqlite": newTestSQLiteBackend(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(t)
+			movies, reviews := b.Movies(), b.Reviews()
+			if err := movies.Store(ctx, Movie{ID: "ran", Title: "Ran"}); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			for _, r := range []Review{
+				{ID: "a", MovieID: "ran", Source: "imdb", URL: "a", Quality: 3},
+				{ID: "b", MovieID: "ran", Source: "imdb", URL: "b"},
+			} {
+				if err := reviews.Store(ctx, r); err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+			}
+
+			if err := reviews.DeleteByMovieID(ctx, "ran"); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			found, err := reviews.FindByMovieID(ctx, "ran")
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(found) != 0 {
+				t.Errorf("exp no reviews, got %d", len(found))
+			}
+
+			// the reviews come back with the movie
+			if err := movies.Delete(ctx, "ran"); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if err := movies.Restore(ctx, "ran"); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			found, err = reviews.FindByMovieID(ctx, "ran")
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(found) != 2 {
+				t.Fatalf("exp 2 reviews, got %d", len(found))
+			}
+			r, err := reviews.FindOne(ctx, "a")
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if r.Quality != 3 {
+				t.Errorf("exp quality 3, got %d", r.Quality)
+			}
+		})
+	}
+}
+
+// failingRestore is a memory backend where taking a movie out of the trash
+// fails.
+type failingRestore struct {
+	*Memory
+}
+
+func (fr failingRestore) Movies() MovieRepository {
+	return failingRestoreMovies{fr.Memory.Movies()}
+}
+
+type failingRestoreMovies struct {
+	MovieRepository
+}
+
+func (fm failingRestoreMovies) Restore(ctx context.Context, id string) error {
+	return errors.New("disk full")
+}
+
+func TestRestoreMovieInTx(t *testing.T) {
+	ctx := testContext(t)
+	mem := NewMemory()
+	movies := mem.Movies()
+	m := Movie{ID: "ran", Title: "Ran", Rating: 8}
+	if err := movies.Store(ctx, m); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := UpdateMovie(ctx, movies, m, func(m *Movie) { m.Rating = 3 }); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := movies.Delete(ctx, "ran"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	revisions, err := mem.Audit().FindByRow(ctx, AuditTableMovie, "ran")
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	if _, err := RestoreMovie(ctx, failingRestore{mem}, "ran", revisions[2].ID); err == nil {
+		t.Fatalf("exp an error, got nil")
+	}
+	trash, err := movies.FindDeleted(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(trash) != 1 || trash[0].Rating != 3 {
+		t.Errorf("exp the movie in the trash as it was, got %v", trash)
+	}
+}
+
+func TestFindByActor(t *testing.T) {
+	for name, b := range map[string]Backend{
+		"memory": NewMemory(),
+		"sqlite": newTestSQLiteBackend(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(t)
+			movies := b.Movies()
+			m := Movie{ID: "ran", Title: "Ran", Rating: 8}
+			if err := movies.Store(ctx, m); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			refresh := func(summary string) {
+				t.Helper()
+				stored, err := movies.FindOne(ctx, "ran")
+				if err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+				stored.Summary = summary
+				if err := movies.Store(WithActor(ctx, ActorTMDB), stored); err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+			}
+			refresh("War.")
+			first, err := b.Audit().FindByActor(ctx, AuditTableMovie, ActorTMDB)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(first) != 1 || first[0].Changes[0].Field != "summary" {
+				t.Fatalf("exp the refresh, got %v", first)
+			}
+			refresh("Lear in Japan.")
+
+			revisions, err := b.Audit().FindByActor(ctx, AuditTableMovie, ActorTMDB)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			newer := NewerThan(revisions, first[0].ID)
+			if len(newer) != 1 || newer[0].Changes[0].New != `"Lear in Japan."` {
+				t.Errorf("exp the second refresh, got %v", newer)
+			}
+			if len(NewerThan(revisions, "")) != 2 {
+				t.Errorf("exp all refreshes, got %d", len(NewerThan(revisions, "")))
+			}
+
+			// the creation was made by the configured actor
+			all, err := b.Audit().FindByRow(ctx, AuditTableMovie, "ran")
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(all) != 3 || all[2].Actor == ActorTMDB {
+				t.Errorf("exp the creation by someone else, got %v", all)
+			}
+		})
+	}
+}
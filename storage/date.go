@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const DateFormat = time.DateOnly
+
+var (
+	ErrInvalidDate = fmt.Errorf("%w date", ErrValidation)
+)
+
+// legacyDateFormats are the ways watch dates were typed in before they were
+// validated.
+var legacyDateFormats = []string{
+	time.DateOnly,
+	"2006-1-2",
+	"2006/1/2",
+	"2006.1.2",
+	"2-1-2006",
+	"2/1/2006",
+	"2.1.2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	time.RFC3339,
+	time.DateTime,
+}
+
+// Date is a day on the calendar, without time or time zone. The zero value
+// means the date is not known.
+type Date struct {
+	t time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf returns the day of t in its own time zone.
+func DateOf(t time.Time) Date {
+	return NewDate(t.Year(), t.Month(), t.Day())
+}
+
+func Today() Date {
+	return DateOf(time.Now())
+}
+
+// ParseDate parses a date in the yyyy-mm-dd format. An empty string is the
+// zero date.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Date{}, nil
+	}
+	t, err := time.Parse(DateFormat, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: %q, use yyyy-mm-dd", ErrInvalidDate, s)
+	}
+
+	return DateOf(t), nil
+}
+
+// parseLegacyDate tries all formats that were found in free text watch dates.
+func parseLegacyDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	for _, f := range legacyDateFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return DateOf(t), nil
+		}
+	}
+
+	return Date{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+func (d Date) Year() int {
+	return d.t.Year()
+}
+
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.t.AddDate(0, 0, n))
+}
+
+func (d Date) Before(o Date) bool {
+	return d.t.Before(o.t)
+}
+
+func (d Date) After(o Date) bool {
+	return d.t.After(o.t)
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(DateFormat)
+}
+
+// Value stores the date as text, which both Postgres and SQLite accept for
+// a DATE column.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v.Year(), v.Month(), v.Day())
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	default:
+		return fmt.Errorf("%w: can not scan %T", ErrInvalidDate, src)
+	}
+
+	return nil
+}
+
+func (d *Date) scanString(s string) error {
+	if len(s) > len(DateFormat) {
+		s = s[:len(DateFormat)]
+	}
+	date, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = date
+
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	date, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = date
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		exp   Date
+		err   error
+	}{
+		{name: "empty", input: "", exp: Date{}},
+		{name: "spaces", input: "  ", exp: Date{}},
+		{name: "date", input: "2024-03-01", exp: NewDate(2024, time.March, 1)},
+		{name: "trimmed", input: " 2024-03-01\n", exp: NewDate(2024, time.March, 1)},
+		{name: "no leading zeros", input: "2024-3-1", err: ErrInvalidDate},
+		{name: "day first", input: "01-03-2024", err: ErrInvalidDate},
+		{name: "no such day", input: "2023-02-29", err: ErrInvalidDate},
+		{name: "text", input: "yesterday", err: ErrInvalidDate},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			act, err := ParseDate(tc.input)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("exp error %v, got %v", tc.err, err)
+			}
+			if act != tc.exp {
+				t.Errorf("exp %v, got %v", tc.exp, act)
+			}
+		})
+	}
+	if !errors.Is(ErrInvalidDate, ErrValidation) {
+		t.Errorf("exp ErrInvalidDate to be a validation error")
+	}
+}
+
+func TestParseLegacyDate(t *testing.T) {
+	exp := NewDate(2019, time.November, 5)
+	for _, input := range []string{
+		"2019-11-05",
+		"2019-11-5",
+		"2019/11/5",
+		"2019.11.05",
+		"5-11-2019",
+		"05/11/2019",
+		"5.11.2019",
+		"5 November 2019",
+		"5 Nov 2019",
+		"November 5, 2019",
+		"Nov 5, 2019",
+		"2019-11-05T21:30:00Z",
+		"2019-11-05 21:30:00",
+		"  2019-11-05 ",
+	} {
+		act, err := parseLegacyDate(input)
+		if err != nil {
+			t.Errorf("%q: exp nil, got %v", input, err)
+			continue
+		}
+		if act != exp {
+			t.Errorf("%q: exp %v, got %v", input, exp, act)
+		}
+	}
+
+	for _, input := range []string{"", "last summer", "2019"} {
+		if _, err := parseLegacyDate(input); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("%q: exp %v, got %v", input, ErrInvalidDate, err)
+		}
+	}
+}
+
+func TestDateScan(t *testing.T) {
+	exp := NewDate(2021, time.July, 14)
+	for _, tc := range []struct {
+		name string
+		src  any
+		exp  Date
+		err  error
+	}{
+		{name: "nil", src: nil, exp: Date{}},
+		{name: "time", src: time.Date(2021, time.July, 14, 23, 0, 0, 0, time.UTC), exp: exp},
+		{name: "string", src: "2021-07-14", exp: exp},
+		{name: "bytes", src: []byte("2021-07-14"), exp: exp},
+		{name: "string with time", src: "2021-07-14T00:00:00Z", exp: exp},
+		{name: "empty string", src: "", exp: Date{}},
+		{name: "invalid", src: "14-07-2021", err: ErrInvalidDate},
+		{name: "number", src: int64(20210714), err: ErrInvalidDate},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var act Date
+			err := act.Scan(tc.src)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("exp error %v, got %v", tc.err, err)
+			}
+			if act != tc.exp {
+				t.Errorf("exp %v, got %v", tc.exp, act)
+			}
+		})
+	}
+}
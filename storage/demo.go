@@ -0,0 +1,144 @@
+package storage
+
+// NewDemo returns an in-memory database with a handful of movies and reviews,
+// so the clients can be tried out without setting up a real database.
+func NewDemo() *Memory {
+	mem := NewMemory()
+	mem.actor = "demo"
+	mem.movies = append(mem.movies,
+		Movie{
+			ID:           "00000000-0000-0000-0000-000000000001",
+			TMDBID:       346,
+			IMDBID:       "tt0047478",
+			Title:        "七人の侍",
+			EnglishTitle: "Seven Samurai",
+			Year:         1954,
+			Directors:    []Person{{TMDBID: 5026, Name: "Akira Kurosawa"}},
+			WatchedOn:    NewDate(2024, 1, 14),
+			Rating:       9,
+			Status:       StatusWatched,
+			Tags:         []string{"japan", "samurai"},
+			Summary:      "A samurai answers a village's request for protection after he falls on hard times. The town needs protection from bandits, so the samurai gathers six others to help him teach the people how to defend themselves.",
+			Comment:      "Three and a half hours that fly by.",
+		},
+		Movie{
+			ID:           "00000000-0000-0000-0000-000000000002",
+			TMDBID:       129,
+			IMDBID:       "tt0245429",
+			Title:        "千と千尋の神隠し",
+			EnglishTitle: "Spirited Away",
+			Year:         2001,
+			Directors:    []Person{{TMDBID: 608, Name: "Hayao Miyazaki"}},
+			WatchedOn:    NewDate(2024, 2, 3),
+			Rating:       8,
+			Status:       StatusWatched,
+			Tags:         []string{"animation", "japan"},
+			Summary:      "A young girl, Chihiro, becomes trapped in a strange new world of spirits. When her parents undergo a mysterious transformation, she must call upon the courage she never knew she had to free her family.",
+		},
+		Movie{
+			ID:           "00000000-0000-0000-0000-000000000003",
+			TMDBID:       62,
+			IMDBID:       "tt0062622",
+			Title:        "2001: A Space Odyssey",
+			EnglishTitle: "2001: A Space Odyssey",
+			Year:         1968,
+			Directors:    []Person{{TMDBID: 240, Name: "Stanley Kubrick"}},
+			WatchedOn:    NewDate(2024, 3, 21),
+			Rating:       7,
+			Status:       StatusWatched,
+			Tags:         []string{"science fiction"},
+			Summary:      "Humanity finds a mysterious object buried beneath the lunar surface and sets off to find its origins with the help of HAL 9000, the world's most advanced super computer.",
+			Comment:      "Needs a big screen.",
+		},
+		Movie{
+			ID:            "00000000-0000-0000-0000-000000000004",
+			TMDBID:        18148,
+			IMDBID:        "tt0046438",
+			Title:         "東京物語",
+			EnglishTitle:  "Tokyo Story",
+			Year:          1953,
+			Directors:     []Person{{TMDBID: 95501, Name: "Yasujirō Ozu"}},
+			Summary:       "The elderly Shūkichi and his wife, Tomi, take the long journey from their small seaside village to visit their adult children in Tokyo. Their elder son, Kōichi, a doctor, and their daughter, Shige, a hairdresser, don't have much time to spend with their aged parents.",
+			Status:        StatusWantToWatch,
+			Priority:      2,
+			RecommendedBy: "Sight and Sound poll",
+		},
+	)
+	mem.reviews = append(mem.reviews,
+		Review{
+			ID:          "00000000-0000-0000-0001-000000000001",
+			MovieID:     "00000000-0000-0000-0000-000000000001",
+			Source:      ReviewSourceIMDB,
+			URL:         "https://www.imdb.com/review/rw0000001/",
+			Review:      "The blueprint for every team-up movie since. If you liked The Magnificent Seven, watch the original.",
+			MovieRating: 10,
+			Mentions:    TitleMentions{Titles: []string{"The Magnificent Seven"}},
+		},
+		Review{
+			ID:          "00000000-0000-0000-0001-000000000002",
+			MovieID:     "00000000-0000-0000-0000-000000000002",
+			Source:      ReviewSourceIMDB,
+			URL:         "https://www.imdb.com/review/rw0000002/",
+			Review:      "Beautiful, strange and a little scary. My kids loved it even more than My Neighbor Totoro.",
+			MovieRating: 9,
+		},
+		Review{
+			ID:          "00000000-0000-0000-0001-000000000003",
+			MovieID:     "00000000-0000-0000-0000-000000000003",
+			Source:      ReviewSourceIMDB,
+			URL:         "https://www.imdb.com/review/rw0000003/",
+			Review:      "Slow, but nothing before or after looks like this.",
+			MovieRating: 8,
+			Quality:     6,
+		},
+	)
+	mem.viewings = append(mem.viewings,
+		Viewing{
+			ID:        "00000000-0000-0000-0002-000000000001",
+			MovieID:   "00000000-0000-0000-0000-000000000001",
+			WatchedOn: NewDate(2011, 9, 30),
+			Rating:    8,
+			Location:  "EYE Filmmuseum",
+		},
+		Viewing{
+			ID:        "00000000-0000-0000-0002-000000000002",
+			MovieID:   "00000000-0000-0000-0000-000000000001",
+			WatchedOn: NewDate(2024, 1, 14),
+			Rating:    9,
+			Notes:     "Three and a half hours that fly by.",
+			Location:  "home",
+		},
+		Viewing{
+			ID:        "00000000-0000-0000-0002-000000000003",
+			MovieID:   "00000000-0000-0000-0000-000000000002",
+			WatchedOn: NewDate(2024, 2, 3),
+			Rating:    8,
+			Location:  "home",
+		},
+		Viewing{
+			ID:        "00000000-0000-0000-0002-000000000004",
+			MovieID:   "00000000-0000-0000-0000-000000000003",
+			WatchedOn: NewDate(2024, 3, 21),
+			Rating:    7,
+			Notes:     "Needs a big screen.",
+			Location:  "home",
+		},
+	)
+	mem.lists = append(mem.lists,
+		List{
+			ID:          "00000000-0000-0000-0003-000000000001",
+			Name:        "Japanese classics",
+			Description: "Where to start with Japanese cinema.",
+			MovieIDs: []string{
+				"00000000-0000-0000-0000-000000000001",
+				"00000000-0000-0000-0000-000000000004",
+				"00000000-0000-0000-0000-000000000002",
+			},
+		},
+	)
+	for i := range mem.movies {
+		mem.movies[i] = mem.movies[i].normalize()
+	}
+
+	return mem
+}
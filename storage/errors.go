@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// The kinds of errors that callers can act on. Every error from a repository
+// or the job queue wraps at most one of them, so errors.Is tells a missing
+// record from an outage.
+var (
+	// ErrNotFound means the record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the change clashes with what is stored, like a
+	// second list with the same name.
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable means the database or a service could not do its work
+	// right now. Trying again later may succeed.
+	ErrUnavailable = errors.New("unavailable")
+	// ErrValidation means the input was rejected. Trying again will not
+	// help.
+	ErrValidation = errors.New("invalid")
+)
+
+// classified tells whether the error already is one of the kinds.
+func classified(err error) bool {
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrUnavailable, ErrValidation} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// postgresError sorts an error of the database in one of the kinds.
+func postgresError(err error) error {
+	var pqErr *pq.Error
+	switch {
+	case err == nil, classified(err):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation":
+		return fmt.Errorf("%w: %w: %v", ErrConflict, ErrPostgresqlFailure, err)
+	case errors.As(err, &pqErr) && (pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23"):
+		// data exceptions and integrity constraint violations
+		return fmt.Errorf("%w: %w: %v", ErrValidation, ErrPostgresqlFailure, err)
+	default:
+		return fmt.Errorf("%w: %w: %v", ErrUnavailable, ErrPostgresqlFailure, err)
+	}
+}
+
+// sqliteError sorts an error of the database in one of the kinds.
+func sqliteError(err error) error {
+	var slErr sqlite3.Error
+	switch {
+	case err == nil, classified(err):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.As(err, &slErr) && (slErr.ExtendedCode == sqlite3.ErrConstraintUnique || slErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey):
+		return fmt.Errorf("%w: %w: %v", ErrConflict, ErrSQLiteFailure, err)
+	case errors.As(err, &slErr) && slErr.Code == sqlite3.ErrConstraint:
+		return fmt.Errorf("%w: %w: %v", ErrValidation, ErrSQLiteFailure, err)
+	default:
+		return fmt.Errorf("%w: %w: %v", ErrUnavailable, ErrSQLiteFailure, err)
+	}
+}
+
+// ErrOutdated means the record was changed by someone else after it was
+// read. Read it again and redo the change.
+var ErrOutdated = fmt.Errorf("%w: record was changed since it was read", ErrConflict)
+
+// checkVersion compares the version that a change was made on with the
+// version that is stored. Zero means the record does not exist.
+func checkVersion(table, id string, stored, version int) error {
+	if stored != version {
+		return fmt.Errorf("%s %s: %w", table, id, ErrOutdated)
+	}
+
+	return nil
+}
+
+// checkUpdated tells whether the conditional update of the record found the
+// version it expected. It catches the changes that slipped in after the
+// version was checked.
+func checkUpdated(table, id string, res sql.Result) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%s %s: %w", table, id, ErrOutdated)
+	}
+
+	return nil
+}
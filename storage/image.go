@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Image is an image that was downloaded to the image cache. The file is
+// named after the hash of the content, so an image that is found at more
+// than one URL is stored once.
+type Image struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+	// File is the path of the image, relative to the cache directory.
+	File      string    `json:"file"`
+	Size      int64     `json:"size"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+type ImageRepository interface {
+	Store(ctx context.Context, i Image) error
+	FindByURL(ctx context.Context, url string) (Image, error)
+}
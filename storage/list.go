@@ -0,0 +1,20 @@
+package storage
+
+import "context"
+
+// List is a named selection of movies, in the order they were put on it.
+type List struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	MovieIDs    []string `json:"movieIDs"`
+}
+
+type ListRepository interface {
+	// Store saves the list, including the order of the movies on it.
+	Store(ctx context.Context, l List) error
+	Delete(ctx context.Context, id string) error
+	FindOne(ctx context.Context, id string) (List, error)
+	// FindAll returns all lists, sorted by name.
+	FindAll(ctx context.Context) ([]List, error)
+}
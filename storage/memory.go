@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory keeps everything in memory. It is meant for tests and demos, nothing
+// survives a restart.
+type Memory struct {
+	mu        sync.Mutex
+	movies    []Movie
+	reviews   []Review
+	viewings  []Viewing
+	lists     []List
+	revisions []Revision
+	images    map[string]Image
+	actor     string
+	// trashed holds the time reviews and viewings were moved to the trash
+	// together with their movie.
+	trashed map[string]time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		movies:    make([]Movie, 0),
+		reviews:   make([]Review, 0),
+		viewings:  make([]Viewing, 0),
+		lists:     make([]List, 0),
+		revisions: make([]Revision, 0),
+		images:    make(map[string]Image),
+		actor:     defaultActor(),
+		trashed:   make(map[string]time.Time),
+	}
+}
+
+func (mem *Memory) Movies() MovieRepository {
+	return NewMemoryMovieRepository(mem)
+}
+
+func (mem *Memory) Reviews() ReviewRepository {
+	return NewMemoryReviewRepository(mem)
+}
+
+func (mem *Memory) Viewings() ViewingRepository {
+	return NewMemoryViewingRepository(mem)
+}
+
+func (mem *Memory) Lists() ListRepository {
+	return NewMemoryListRepository(mem)
+}
+
+func (mem *Memory) Audit() AuditRepository {
+	return NewMemoryAuditRepository(mem)
+}
+
+func (mem *Memory) Images() ImageRepository {
+	return NewMemoryImageRepository(mem)
+}
+
+// InTx runs fn and puts everything back the way it was when fn returns an
+// error. Unlike a database transaction, it does not hide the changes from
+// others while fn runs, and jobs added to the memory job queue stay.
+func (mem *Memory) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	mem.mu.Lock()
+	movies, reviews, viewings := slices.Clone(mem.movies), slices.Clone(mem.reviews), slices.Clone(mem.viewings)
+	lists := make([]List, 0, len(mem.lists))
+	for _, l := range mem.lists {
+		lists = append(lists, copyList(l))
+	}
+	revisions, trashed, images := slices.Clone(mem.revisions), maps.Clone(mem.trashed), maps.Clone(mem.images)
+	mem.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		mem.mu.Lock()
+		defer mem.mu.Unlock()
+		mem.movies, mem.reviews, mem.viewings, mem.lists = movies, reviews, viewings, lists
+		mem.revisions, mem.trashed, mem.images = revisions, trashed, images
+		return err
+	}
+
+	return nil
+}
+
+// record keeps the changes between the old and the new version of a record.
+// The caller must hold the lock.
+func (mem *Memory) record(ctx context.Context, table, rowID string, old, new any) error {
+	rev, err := newRevision(table, rowID, actorOf(ctx, mem.actor), old, new)
+	if err != nil {
+		return err
+	}
+	if len(rev.Changes) == 0 {
+		return nil
+	}
+	rev.ChangedAt = time.Now()
+	mem.revisions = append(mem.revisions, rev)
+
+	return nil
+}
+
+func copyMovie(m Movie) Movie {
+	m.Directors = slices.Clone(m.Directors)
+	m.Crew = slices.Clone(m.Crew)
+	m.Cast = slices.Clone(m.Cast)
+	m.Tags = slices.Clone(m.Tags)
+	m.Genres = slices.Clone(m.Genres)
+	m.Countries = slices.Clone(m.Countries)
+	return m
+}
+
+func copyReview(r Review) Review {
+	r.Mentions.Titles = slices.Clone(r.Mentions.Titles)
+	return r
+}
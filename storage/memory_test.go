@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryMovieStore(t *testing.T) {
+	ctx := testContext(t)
+	movies := NewMemory().Movies()
+
+	if err := movies.Store(ctx, Movie{ID: "ran", Title: "Ran", Rating: 8}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	stored, err := movies.FindOne(ctx, "ran")
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if stored.Version != 1 {
+		t.Errorf("exp version 1, got %d", stored.Version)
+	}
+
+	// storing the same id again updates the movie
+	stored.Rating = 9
+	if err := movies.Store(ctx, stored); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	all, err := movies.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("exp 1 movie, got %d", len(all))
+	}
+	if all[0].Rating != 9 || all[0].Version != 2 {
+		t.Errorf("exp rating 9 and version 2, got %d and %d", all[0].Rating, all[0].Version)
+	}
+
+	// the copy that was read before the update is outdated
+	stored.Rating = 3
+	if err := movies.Store(ctx, stored); !errors.Is(err, ErrOutdated) {
+		t.Errorf("exp %v, got %v", ErrOutdated, err)
+	}
+
+	// a movie without an id gets one
+	if err := movies.Store(ctx, Movie{Title: "Ikiru"}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	all, err = movies.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("exp 2 movies, got %d", len(all))
+	}
+	for _, m := range all {
+		if m.ID == "" {
+			t.Errorf("exp an id, got none for %s", m.Title)
+		}
+	}
+
+	if _, err := movies.FindOne(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("exp %v, got %v", ErrNotFound, err)
+	}
+}
+
+func TestMemoryReviewFind(t *testing.T) {
+	ctx := testContext(t)
+	mem := NewMemory()
+	reviews := mem.Reviews()
+	for _, r := range []Review{
+		{ID: "rated", MovieID: "ran", Source: "imdb", URL: "a", Quality: 4, Mentions: TitleMentions{Titles: []string{"Ikiru"}}},
+		{ID: "unrated", MovieID: "ran", Source: "imdb", URL: "b", Mentions: TitleMentions{Titles: []string{}}},
+		{ID: "titled", MovieID: "ikiru", Source: "imdb", URL: "c", Mentions: TitleMentions{Titles: []string{"Ran"}}},
+	} {
+		if err := reviews.Store(ctx, r); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+
+	next, err := reviews.FindNextUnrated(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if next.ID != "unrated" {
+		t.Errorf("exp unrated, got %s", next.ID)
+	}
+	unrated, err := reviews.FindUnrated(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(unrated) != 2 {
+		t.Errorf("exp 2 unrated reviews, got %d", len(unrated))
+	}
+
+	next, err = reviews.FindNextNoTitles(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if next.ID != "unrated" {
+		t.Errorf("exp unrated, got %s", next.ID)
+	}
+	noTitles, err := reviews.FindNoTitles(ctx)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(noTitles) != 1 {
+		t.Errorf("exp 1 review without titles, got %d", len(noTitles))
+	}
+
+	// with every review rated, there is no next one
+	next.Quality = 2
+	if err := reviews.Store(ctx, next); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := reviews.Store(ctx, Review{ID: "other", MovieID: "ikiru", Source: "imdb", URL: "a"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("exp %v for a second review at the same url, got %v", ErrConflict, err)
+	}
+	stored, err := reviews.FindOne(ctx, "titled")
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	stored.Quality = 1
+	if err := reviews.Store(ctx, stored); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if _, err := reviews.FindNextUnrated(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("exp %v, got %v", ErrNotFound, err)
+	}
+
+	byMovie, err := reviews.FindByMovieID(ctx, "ran")
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(byMovie) != 2 {
+		t.Errorf("exp 2 reviews of ran, got %d", len(byMovie))
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import "context"
+
+import "slices"
+
+type MemoryAuditRepository struct {
+	db *Memory
+}
+
+func NewMemoryAuditRepository(db *Memory) *MemoryAuditRepository {
+	return &MemoryAuditRepository{
+		db: db,
+	}
+}
+
+func (ar *MemoryAuditRepository) FindByRow(ctx context.Context, table, rowID string) ([]Revision, error) {
+	ar.db.mu.Lock()
+	defer ar.db.mu.Unlock()
+
+	revisions := make([]Revision, 0)
+	for i := len(ar.db.revisions) - 1; i >= 0; i-- {
+		rev := ar.db.revisions[i]
+		if rev.Table == table && rev.RowID == rowID {
+			rev.Changes = slices.Clone(rev.Changes)
+			revisions = append(revisions, rev)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (ar *MemoryAuditRepository) FindByActor(ctx context.Context, table, actor string) ([]Revision, error) {
+	ar.db.mu.Lock()
+	defer ar.db.mu.Unlock()
+
+	revisions := make([]Revision, 0)
+	for i := len(ar.db.revisions) - 1; i >= 0; i-- {
+		rev := ar.db.revisions[i]
+		if rev.Table == table && rev.Actor == actor {
+			rev.Changes = slices.Clone(rev.Changes)
+			revisions = append(revisions, rev)
+		}
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import "context"
+
+type MemoryImageRepository struct {
+	db *Memory
+}
+
+func NewMemoryImageRepository(db *Memory) *MemoryImageRepository {
+	return &MemoryImageRepository{
+		db: db,
+	}
+}
+
+func (ir *MemoryImageRepository) Store(ctx context.Context, i Image) error {
+	ir.db.mu.Lock()
+	defer ir.db.mu.Unlock()
+
+	ir.db.images[i.URL] = i
+
+	return nil
+}
+
+func (ir *MemoryImageRepository) FindByURL(ctx context.Context, url string) (Image, error) {
+	ir.db.mu.Lock()
+	defer ir.db.mu.Unlock()
+
+	i, ok := ir.db.images[url]
+	if !ok {
+		return Image{}, ErrNotFound
+	}
+
+	return i, nil
+}
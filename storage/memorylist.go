@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type MemoryListRepository struct {
+	db *Memory
+}
+
+func NewMemoryListRepository(db *Memory) *MemoryListRepository {
+	return &MemoryListRepository{
+		db: db,
+	}
+}
+
+func (lr *MemoryListRepository) Store(ctx context.Context, l List) error {
+	lr.db.mu.Lock()
+	defer lr.db.mu.Unlock()
+
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+
+	for _, other := range lr.db.lists {
+		if other.Name == l.Name && other.ID != l.ID {
+			return fmt.Errorf("%w: there is already a list named %s", ErrConflict, l.Name)
+		}
+	}
+	for i := range lr.db.lists {
+		if lr.db.lists[i].ID == l.ID {
+			lr.db.lists[i] = copyList(l)
+			return nil
+		}
+	}
+	lr.db.lists = append(lr.db.lists, copyList(l))
+
+	return nil
+}
+
+func (lr *MemoryListRepository) Delete(ctx context.Context, id string) error {
+	lr.db.mu.Lock()
+	defer lr.db.mu.Unlock()
+
+	for i := range lr.db.lists {
+		if lr.db.lists[i].ID == id {
+			lr.db.lists = append(lr.db.lists[:i], lr.db.lists[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (lr *MemoryListRepository) FindOne(ctx context.Context, id string) (List, error) {
+	lr.db.mu.Lock()
+	defer lr.db.mu.Unlock()
+
+	for _, l := range lr.db.lists {
+		if l.ID == id {
+			return copyList(l), nil
+		}
+	}
+
+	return List{}, ErrNotFound
+}
+
+func (lr *MemoryListRepository) FindAll(ctx context.Context) ([]List, error) {
+	lr.db.mu.Lock()
+	defer lr.db.mu.Unlock()
+
+	lists := make([]List, 0, len(lr.db.lists))
+	for _, l := range lr.db.lists {
+		lists = append(lists, copyList(l))
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+
+	return lists, nil
+}
+
+func copyList(l List) List {
+	l.MovieIDs = slices.Clone(l.MovieIDs)
+	if l.MovieIDs == nil {
+		l.MovieIDs = make([]string, 0)
+	}
+	return l
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MemoryMovieRepository struct {
+	db *Memory
+}
+
+func NewMemoryMovieRepository(db *Memory) *MemoryMovieRepository {
+	return &MemoryMovieRepository{
+		db: db,
+	}
+}
+
+func (mr *MemoryMovieRepository) Store(ctx context.Context, m Movie) error {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	m = m.normalize()
+
+	for i := range mr.db.movies {
+		if mr.db.movies[i].ID == m.ID {
+			if err := checkVersion(AuditTableMovie, m.ID, mr.db.movies[i].Version, m.Version); err != nil {
+				return err
+			}
+			m.DeletedAt = mr.db.movies[i].DeletedAt
+			if err := mr.db.record(ctx, AuditTableMovie, m.ID, mr.db.movies[i], m); err != nil {
+				return err
+			}
+			m.Version++
+			mr.db.movies[i] = copyMovie(m)
+			return nil
+		}
+	}
+	if err := checkVersion(AuditTableMovie, m.ID, 0, m.Version); err != nil {
+		return err
+	}
+	m.DeletedAt = time.Time{}
+	if err := mr.db.record(ctx, AuditTableMovie, m.ID, nil, m); err != nil {
+		return err
+	}
+	m.Version = 1
+	mr.db.movies = append(mr.db.movies, copyMovie(m))
+
+	return nil
+}
+
+func (mr *MemoryMovieRepository) Delete(ctx context.Context, id string) error {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	for i, m := range mr.db.movies {
+		if m.ID != id {
+			continue
+		}
+		if !m.DeletedAt.IsZero() {
+			return nil
+		}
+		deleted := copyMovie(m)
+		deleted.DeletedAt = time.Now().UTC().Truncate(time.Second)
+		deleted.Version++
+		if err := mr.db.record(ctx, AuditTableMovie, id, m, deleted); err != nil {
+			return err
+		}
+		mr.db.movies[i] = deleted
+		for _, r := range mr.db.reviews {
+			if _, ok := mr.db.trashed[r.ID]; r.MovieID == id && !ok {
+				mr.db.trashed[r.ID] = deleted.DeletedAt
+			}
+		}
+		for _, v := range mr.db.viewings {
+			if _, ok := mr.db.trashed[v.ID]; v.MovieID == id && !ok {
+				mr.db.trashed[v.ID] = deleted.DeletedAt
+			}
+		}
+
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+func (mr *MemoryMovieRepository) Restore(ctx context.Context, id string) error {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	for i, m := range mr.db.movies {
+		if m.ID != id {
+			continue
+		}
+		if m.DeletedAt.IsZero() {
+			return nil
+		}
+		restored := copyMovie(m)
+		restored.DeletedAt = time.Time{}
+		restored.Version++
+		if err := mr.db.record(ctx, AuditTableMovie, id, m, restored); err != nil {
+			return err
+		}
+		mr.db.movies[i] = restored
+		for _, r := range mr.db.reviews {
+			if r.MovieID == id {
+				delete(mr.db.trashed, r.ID)
+			}
+		}
+		for _, v := range mr.db.viewings {
+			if v.MovieID == id {
+				delete(mr.db.trashed, v.ID)
+			}
+		}
+
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+func (mr *MemoryMovieRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	purged := make(map[string]bool)
+	movies := make([]Movie, 0, len(mr.db.movies))
+	for _, m := range mr.db.movies {
+		if !m.DeletedAt.IsZero() && m.DeletedAt.Before(before) {
+			if err := mr.db.record(ctx, AuditTableMovie, m.ID, m, nil); err != nil {
+				return 0, err
+			}
+			purged[m.ID] = true
+			continue
+		}
+		movies = append(movies, m)
+	}
+	mr.db.movies = movies
+
+	gone := func(id, movieID string) bool {
+		deletedAt, trashed := mr.db.trashed[id]
+		if purged[movieID] || (trashed && deletedAt.Before(before)) {
+			delete(mr.db.trashed, id)
+			return true
+		}
+		return false
+	}
+	mr.db.reviews = slices.DeleteFunc(mr.db.reviews, func(r Review) bool { return gone(r.ID, r.MovieID) })
+	mr.db.viewings = slices.DeleteFunc(mr.db.viewings, func(v Viewing) bool { return gone(v.ID, v.MovieID) })
+	for i := range mr.db.lists {
+		mr.db.lists[i].MovieIDs = slices.DeleteFunc(mr.db.lists[i].MovieIDs, func(movieID string) bool {
+			return purged[movieID]
+		})
+	}
+
+	return len(purged), nil
+}
+
+func (mr *MemoryMovieRepository) FindOne(ctx context.Context, id string) (Movie, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	for _, m := range mr.db.movies {
+		if m.ID == id {
+			return copyMovie(m), nil
+		}
+	}
+
+	return Movie{}, ErrNotFound
+}
+
+func (mr *MemoryMovieRepository) FindAll(ctx context.Context) ([]Movie, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	movies := make([]Movie, 0, len(mr.db.movies))
+	for _, m := range mr.db.movies {
+		if m.DeletedAt.IsZero() {
+			movies = append(movies, copyMovie(m))
+		}
+	}
+
+	return movies, nil
+}
+
+func (mr *MemoryMovieRepository) FindByPerson(ctx context.Context, personID int64, role Role) ([]Movie, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	movies := make([]Movie, 0)
+	for _, m := range mr.db.movies {
+		if !m.DeletedAt.IsZero() {
+			continue
+		}
+		for _, p := range m.People(role) {
+			if p.TMDBID == personID {
+				movies = append(movies, copyMovie(m))
+				break
+			}
+		}
+	}
+
+	return movies, nil
+}
+
+func (mr *MemoryMovieRepository) FindByStatus(ctx context.Context, status Status) ([]Movie, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	movies := make([]Movie, 0)
+	for _, m := range mr.db.movies {
+		if m.Status == status && m.DeletedAt.IsZero() {
+			movies = append(movies, copyMovie(m))
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		if movies[i].Priority != movies[j].Priority {
+			return movies[i].Priority > movies[j].Priority
+		}
+		return movies[i].Title < movies[j].Title
+	})
+
+	return movies, nil
+}
+
+func (mr *MemoryMovieRepository) Query(ctx context.Context, q MovieQuery) (MoviePage, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	page, err := queryMovies(mr.db.movies, q)
+	if err != nil {
+		return MoviePage{}, err
+	}
+	for i := range page.Movies {
+		page.Movies[i] = copyMovie(page.Movies[i])
+	}
+
+	return page, nil
+}
+
+func (mr *MemoryMovieRepository) Search(ctx context.Context, text string, statuses []Status, limit int) ([]SearchHit, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	terms := searchTerms(text)
+	reviews := make(map[string][]string)
+	for _, r := range mr.db.reviews {
+		if _, ok := mr.db.trashed[r.ID]; !ok {
+			reviews[r.MovieID] = append(reviews[r.MovieID], r.Review)
+		}
+	}
+
+	hits := make([]SearchHit, 0)
+	for _, m := range mr.db.movies {
+		if !m.DeletedAt.IsZero() || (len(statuses) > 0 && !slices.Contains(statuses, m.Status)) {
+			continue
+		}
+		if h, ok := rankMovie(copyMovie(m), reviews[m.ID], terms); ok {
+			hits = append(hits, h)
+		}
+	}
+
+	return sortHits(hits, limit), nil
+}
+
+func (mr *MemoryMovieRepository) FindDeleted(ctx context.Context) ([]Movie, error) {
+	mr.db.mu.Lock()
+	defer mr.db.mu.Unlock()
+
+	movies := make([]Movie, 0)
+	for _, m := range mr.db.movies {
+		if !m.DeletedAt.IsZero() {
+			movies = append(movies, copyMovie(m))
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		if !movies[i].DeletedAt.Equal(movies[j].DeletedAt) {
+			return movies[i].DeletedAt.After(movies[j].DeletedAt)
+		}
+		return movies[i].Title < movies[j].Title
+	})
+
+	return movies, nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type MemoryReviewRepository struct {
+	db *Memory
+}
+
+func NewMemoryReviewRepository(db *Memory) *MemoryReviewRepository {
+	return &MemoryReviewRepository{
+		db: db,
+	}
+}
+
+func (rr *MemoryReviewRepository) Store(ctx context.Context, r Review) error {
+	rr.db.mu.Lock()
+	defer rr.db.mu.Unlock()
+
+	for _, other := range rr.db.reviews {
+		if other.ID != r.ID && other.Source == r.Source && other.URL == r.URL {
+			return fmt.Errorf("%w: there is already a review at %s", ErrConflict, r.URL)
+		}
+	}
+	for i := range rr.db.reviews {
+		if rr.db.reviews[i].ID == r.ID {
+			if err := checkVersion(AuditTableReview, r.ID, rr.db.reviews[i].Version, r.Version); err != nil {
+				return err
+			}
+			if err := rr.db.record(ctx, AuditTableReview, r.ID, rr.db.reviews[i], r); err != nil {
+				return err
+			}
+			r.Version++
+			rr.db.reviews[i] = copyReview(r)
+			return nil
+		}
+	}
+	if err := checkVersion(AuditTableReview, r.ID, 0, r.Version); err != nil {
+		return err
+	}
+	if err := rr.db.record(ctx, AuditTableReview, r.ID, nil, r); err != nil {
+		return err
+	}
+	r.Version = 1
+	rr.db.reviews = append(rr.db.reviews, copyReview(r))
+
+	return nil
+}
+
+func (rr *MemoryReviewRepository) FindOne(ctx context.Context, id string) (Review, error) {
+	return rr.findOne(func(r Review) bool { return r.ID == id })
+}
+
+func (rr *MemoryReviewRepository) FindByMovieID(ctx context.Context, movieID string) ([]Review, error) {
+	return rr.find(func(r Review) bool { return r.MovieID == movieID })
+}
+
+func (rr *MemoryReviewRepository) FindNextUnrated(ctx context.Context) (Review, error) {
+	return rr.findOne(unrated)
+}
+
+func (rr *MemoryReviewRepository) FindUnrated(ctx context.Context) ([]Review, error) {
+	return rr.find(unrated)
+}
+
+func (rr *MemoryReviewRepository) FindNextNoTitles(ctx context.Context) (Review, error) {
+	return rr.findOne(noTitles)
+}
+
+func (rr *MemoryReviewRepository) FindNoTitles(ctx context.Context) ([]Review, error) {
+	return rr.find(noTitles)
+}
+
+func (rr *MemoryReviewRepository) FindAll(ctx context.Context) ([]Review, error) {
+	return rr.find(func(r Review) bool { return true })
+}
+
+func (rr *MemoryReviewRepository) DeleteByMovieID(ctx context.Context, id string) error {
+	rr.db.mu.Lock()
+	defer rr.db.mu.Unlock()
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	for _, r := range rr.db.reviews {
+		if _, ok := rr.db.trashed[r.ID]; r.MovieID != id || ok {
+			continue
+		}
+		if err := rr.db.record(ctx, AuditTableReview, r.ID, r, nil); err != nil {
+			return err
+		}
+		rr.db.trashed[r.ID] = deletedAt
+	}
+
+	return nil
+}
+
+func (rr *MemoryReviewRepository) findOne(match func(Review) bool) (Review, error) {
+	rr.db.mu.Lock()
+	defer rr.db.mu.Unlock()
+
+	for _, r := range rr.db.reviews {
+		if _, ok := rr.db.trashed[r.ID]; match(r) && !ok {
+			return copyReview(r), nil
+		}
+	}
+
+	return Review{}, ErrNotFound
+}
+
+func (rr *MemoryReviewRepository) find(match func(Review) bool) ([]Review, error) {
+	rr.db.mu.Lock()
+	defer rr.db.mu.Unlock()
+
+	reviews := make([]Review, 0)
+	for _, r := range rr.db.reviews {
+		if _, ok := rr.db.trashed[r.ID]; match(r) && !ok {
+			reviews = append(reviews, copyReview(r))
+		}
+	}
+
+	return reviews, nil
+}
+
+func unrated(r Review) bool  { return r.Quality == 0 }
+func noTitles(r Review) bool { return len(r.Mentions.Titles) == 0 }
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type MemoryViewingRepository struct {
+	db *Memory
+}
+
+func NewMemoryViewingRepository(db *Memory) *MemoryViewingRepository {
+	return &MemoryViewingRepository{
+		db: db,
+	}
+}
+
+func (vr *MemoryViewingRepository) Store(ctx context.Context, v Viewing) error {
+	vr.db.mu.Lock()
+	defer vr.db.mu.Unlock()
+
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+
+	for i := range vr.db.viewings {
+		if vr.db.viewings[i].ID == v.ID {
+			vr.db.viewings[i] = v
+			return nil
+		}
+	}
+	vr.db.viewings = append(vr.db.viewings, v)
+
+	return nil
+}
+
+func (vr *MemoryViewingRepository) Delete(ctx context.Context, id string) error {
+	vr.db.mu.Lock()
+	defer vr.db.mu.Unlock()
+
+	for i := range vr.db.viewings {
+		if vr.db.viewings[i].ID == id {
+			delete(vr.db.trashed, id)
+			vr.db.viewings = append(vr.db.viewings[:i], vr.db.viewings[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (vr *MemoryViewingRepository) FindOne(ctx context.Context, id string) (Viewing, error) {
+	vr.db.mu.Lock()
+	defer vr.db.mu.Unlock()
+
+	for _, v := range vr.db.viewings {
+		if _, ok := vr.db.trashed[v.ID]; v.ID == id && !ok {
+			return v, nil
+		}
+	}
+
+	return Viewing{}, ErrNotFound
+}
+
+func (vr *MemoryViewingRepository) FindByMovieID(ctx context.Context, movieID string) ([]Viewing, error) {
+	return vr.find(func(v Viewing) bool { return v.MovieID == movieID })
+}
+
+func (vr *MemoryViewingRepository) FindAll(ctx context.Context) ([]Viewing, error) {
+	return vr.find(func(v Viewing) bool { return true })
+}
+
+func (vr *MemoryViewingRepository) find(match func(Viewing) bool) ([]Viewing, error) {
+	vr.db.mu.Lock()
+	defer vr.db.mu.Unlock()
+
+	viewings := make([]Viewing, 0)
+	for _, v := range vr.db.viewings {
+		if _, ok := vr.db.trashed[v.ID]; match(v) && !ok {
+			viewings = append(viewings, v)
+		}
+	}
+	sort.Slice(viewings, func(i, j int) bool {
+		if viewings[i].WatchedOn != viewings[j].WatchedOn {
+			return viewings[i].WatchedOn.After(viewings[j].WatchedOn)
+		}
+		return viewings[i].ID < viewings[j].ID
+	})
+
+	return viewings, nil
+}
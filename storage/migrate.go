@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotEnoughSQLMigrations   = errors.New("not enough sql migrations")
+	ErrIncompatibleSQLMigration = errors.New("incompatible sql migration")
+	ErrIrreversibleMigration    = errors.New("migration can not be reverted")
+	ErrUnknownMigrationVersion  = errors.New("unknown migration version")
+)
+
+// Migration is a numbered schema change. Down must undo everything Up does.
+type Migration struct {
+	Version int
+	Up      string
+	Down    string
+	// Convert runs after Up in the same transaction, for changes to the
+	// data that can not be expressed in SQL.
+	Convert func(tx *sql.Tx) error
+}
+
+// Checksum identifies the Up query. Whitespace is collapsed first, so
+// reformatting an old migration does not make it incompatible.
+func (m Migration) Checksum() string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(normalizeSQL(m.Up))))
+}
+
+// The bookkeeping of the applied migrations.
+const (
+	insertMigration = `INSERT INTO schema_migration (version, checksum) VALUES (?, ?)`
+	deleteMigration = `DELETE FROM schema_migration WHERE version = ?`
+)
+
+type MigrationStatus struct {
+	Version   int
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+func newMigrator(db *sql.DB, d dialect, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		dialect:    d,
+		migrations: migrations,
+	}
+}
+
+// OpenMigrator connects to the configured database without applying any
+// migrations.
+func OpenMigrator(conf Config) (*Migrator, error) {
+	switch conf.Type {
+	case TypePostgres:
+		pg, err := openPostgres(conf.connStr())
+		if err != nil {
+			return nil, err
+		}
+		return pg.Migrator(), nil
+	case TypeSQLite:
+		sl, err := openSQLite(conf.Path)
+		if err != nil {
+			return nil, err
+		}
+		return sl.Migrator(), nil
+	default:
+		return nil, fmt.Errorf("database type %s has no migrations", conf.Type)
+	}
+}
+
+// Latest is the highest version known to the code.
+func (mg *Migrator) Latest() int {
+	return len(mg.migrations)
+}
+
+// Current is the highest version that is applied to the database.
+func (mg *Migrator) Current() (int, error) {
+	applied, err := mg.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	var current int
+	for version := range applied {
+		current = max(current, version)
+	}
+
+	return current, nil
+}
+
+func (mg *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := mg.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(mg.migrations))
+	for _, m := range mg.migrations {
+		s := MigrationStatus{
+			Version: m.Version,
+		}
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.appliedAt
+			s.Modified = a.checksum != m.Checksum()
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
+
+func (mg *Migrator) Up() error {
+	return mg.To(mg.Latest())
+}
+
+func (mg *Migrator) Down() error {
+	current, err := mg.Current()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	return mg.To(current - 1)
+}
+
+// To migrates up or down until version is the latest applied migration.
+func (mg *Migrator) To(version int) error {
+	if version < 0 || version > mg.Latest() {
+		return fmt.Errorf("%w: %d", ErrUnknownMigrationVersion, version)
+	}
+	applied, err := mg.applied()
+	if err != nil {
+		return err
+	}
+	for v, a := range applied {
+		if v > mg.Latest() {
+			return fmt.Errorf("%w: database has version %d", ErrNotEnoughSQLMigrations, v)
+		}
+		if a.checksum != mg.migrations[v-1].Checksum() {
+			return fmt.Errorf("%w: version %d was changed after it was applied", ErrIncompatibleSQLMigration, v)
+		}
+	}
+
+	// down
+	for i := len(mg.migrations) - 1; i >= version; i-- {
+		m := mg.migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return fmt.Errorf("%w: %d", ErrIrreversibleMigration, m.Version)
+		}
+		if err := mg.apply(m.Down, nil, deleteMigration, m.Version); err != nil {
+			return err
+		}
+	}
+
+	// up
+	for _, m := range mg.migrations[:version] {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := mg.apply(m.Up, m.Convert, insertMigration, m.Version, m.Checksum()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mg *Migrator) apply(query string, convert func(*sql.Tx) error, register string, args ...any) error {
+	tx, err := mg.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("%w: version %d: %v", mg.dialect.failure, args[0], err)
+	}
+	if convert != nil {
+		if err := convert(tx); err != nil {
+			return fmt.Errorf("%w: version %d: %v", mg.dialect.failure, args[0], err)
+		}
+	}
+	if _, err := tx.Exec(mg.dialect.rebind(register), args...); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+
+	return nil
+}
+
+func (mg *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := mg.prepare(); err != nil {
+		return nil, err
+	}
+
+	rows, err := mg.db.Query(`SELECT version, checksum, applied_at FROM schema_migration ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", mg.dialect.failure, err)
+		}
+		applied[version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// prepare creates the admin table and takes over the bookkeeping of databases
+// that were migrated with the old migration table, that only stored the queries.
+func (mg *Migrator) prepare() error {
+	if _, err := mg.db.Exec(`
+CREATE TABLE IF NOT EXISTS schema_migration
+(
+    version INTEGER PRIMARY KEY,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+
+	var legacy int
+	if err := mg.db.QueryRow(mg.dialect.rebind(mg.dialect.tableExists), "migration").Scan(&legacy); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	if legacy == 0 {
+		return nil
+	}
+
+	rows, err := mg.db.Query(`SELECT query FROM migration ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	existing := []string{}
+	for rows.Next() {
+		var query string
+		if err := rows.Scan(&query); err != nil {
+			rows.Close()
+			return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+		}
+		existing = append(existing, query)
+	}
+	rows.Close()
+
+	if len(existing) > len(mg.migrations) {
+		return ErrNotEnoughSQLMigrations
+	}
+	for i, query := range existing {
+		if normalizeSQL(query) != normalizeSQL(mg.migrations[i].Up) {
+			return fmt.Errorf("%w: version %d", ErrIncompatibleSQLMigration, i+1)
+		}
+	}
+
+	tx, err := mg.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	defer tx.Rollback()
+	for i := range existing {
+		m := mg.migrations[i]
+		if _, err := tx.Exec(mg.dialect.rebind(insertMigration), m.Version, m.Checksum()); err != nil {
+			return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+		}
+	}
+	if _, err := tx.Exec(`DROP TABLE migration`); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+
+	return nil
+}
+
+func normalizeSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// UnparsedDate is a free text watch date that could not be converted to a
+// date when the column got its type. It is reported until a date is set.
+type UnparsedDate struct {
+	Table string
+	ID    string
+	Value string
+}
+
+func (mg *Migrator) UnparsedDates() ([]UnparsedDate, error) {
+	var exists int
+	if err := mg.db.QueryRow(mg.dialect.rebind(mg.dialect.tableExists), "unparsed_date").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	if exists == 0 {
+		return []UnparsedDate{}, nil
+	}
+
+	rows, err := mg.db.Query(`
+SELECT table_name, row_id, value
+FROM unparsed_date
+WHERE (table_name = 'movie' AND row_id IN (SELECT id FROM movie WHERE watched_on IS NULL))
+  OR (table_name = 'viewing' AND row_id IN (SELECT id FROM viewing WHERE watched_on IS NULL))
+ORDER BY table_name, row_id`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", mg.dialect.failure, err)
+	}
+	defer rows.Close()
+
+	dates := make([]UnparsedDate, 0)
+	for rows.Next() {
+		var d UnparsedDate
+		if err := rows.Scan(&d.Table, &d.ID, &d.Value); err != nil {
+			return nil, fmt.Errorf("%w: %v", mg.dialect.failure, err)
+		}
+		dates = append(dates, d)
+	}
+
+	return dates, rows.Err()
+}
+
+// convertWatchedOn parses the free text watch dates, that were moved to the
+// watched_on_text column, into the typed watched_on column. Values that can
+// not be parsed are kept in unparsed_date.
+func convertWatchedOn(update, report string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, table := range []string{"movie", "viewing"} {
+			rows, err := tx.Query(fmt.Sprintf(`SELECT id, watched_on_text FROM %s WHERE watched_on_text <> ''`, table))
+			if err != nil {
+				return err
+			}
+			values := make(map[string]string)
+			for rows.Next() {
+				var id, value string
+				if err := rows.Scan(&id, &value); err != nil {
+					rows.Close()
+					return err
+				}
+				values[id] = value
+			}
+			rows.Close()
+
+			for id, value := range values {
+				date, err := parseLegacyDate(value)
+				if err != nil {
+					if _, err := tx.Exec(report, table, id, value); err != nil {
+						return err
+					}
+					continue
+				}
+				if _, err := tx.Exec(fmt.Sprintf(update, table), date, id); err != nil {
+					return err
+				}
+			}
+
+			if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s DROP COLUMN watched_on_text`, table)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// backfillDirectors links the directors that movies have as text to the
+// movies as people. It runs when the person tables are created, so that
+// movies that are not on TMDB keep their directors. The people get a negative
+// id, as their TMDB id is not known.
+func backfillDirectors(d dialect) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT id, directors FROM movie WHERE directors <> '' ORDER BY id`)
+		if err != nil {
+			return err
+		}
+		type movieDirectors struct{ id, names string }
+		movies := make([]movieDirectors, 0)
+		for rows.Next() {
+			var md movieDirectors
+			if err := rows.Scan(&md.id, &md.names); err != nil {
+				rows.Close()
+				return err
+			}
+			movies = append(movies, md)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		people := make(map[string]int64)
+		for _, md := range movies {
+			position := 0
+			linked := make(map[int64]bool)
+			for _, name := range strings.Split(md.names, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+				personID, ok := people[name]
+				if !ok {
+					personID = -int64(len(people) + 1)
+					if _, err := tx.Exec(d.rebind(`INSERT INTO person (tmdb_id, name) VALUES (?, ?)`), personID, name); err != nil {
+						return err
+					}
+					people[name] = personID
+				}
+				if linked[personID] {
+					continue
+				}
+				linked[personID] = true
+
+				if _, err := tx.Exec(d.rebind(`
+INSERT INTO movie_person (movie_id, person_id, role, position)
+VALUES (?, ?, 'director', ?)`), md.id, personID, position); err != nil {
+					return err
+				}
+				position++
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestSQLite opens an empty SQLite database that is removed after the
+// test.
+func newTestSQLite(t *testing.T) *SQL {
+	t.Helper()
+	db, err := openSQLite(filepath.Join(t.TempDir(), "emdb.db"))
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	t.Cleanup(func() { db.db.Close() })
+
+	return db
+}
+
+var testMigrations = []Migration{
+	{
+		Version: 1,
+		Up:      `CREATE TABLE one (id INTEGER PRIMARY KEY);`,
+		Down:    `DROP TABLE one;`,
+	},
+	{
+		Version: 2,
+		Up:      `CREATE TABLE two (id INTEGER PRIMARY KEY);`,
+		Down:    `DROP TABLE two;`,
+	},
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	t.Run("test", func(t *testing.T) { checkUpDown(t, testMigrations) })
+	t.Run("sqlite", func(t *testing.T) { checkUpDown(t, sqliteMigrations) })
+}
+
+// checkUpDown applies all migrations, reverts them and applies them again.
+func checkUpDown(t *testing.T, migrations []Migration) {
+	t.Helper()
+	db := newTestSQLite(t)
+	mg := newMigrator(db.db, sqliteDialect, migrations)
+
+	if err := mg.Up(); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if current, err := mg.Current(); err != nil || current != len(migrations) {
+		t.Errorf("exp %d, got %d and %v", len(migrations), current, err)
+	}
+	status, err := mg.Status()
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	for _, s := range status {
+		if !s.Applied || s.Modified {
+			t.Errorf("exp version %d applied and not modified, got %v and %v", s.Version, s.Applied, s.Modified)
+		}
+	}
+
+	if err := mg.To(0); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if current, _ := mg.Current(); current != 0 {
+		t.Errorf("exp 0, got %d", current)
+	}
+	if err := mg.Up(); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if current, _ := mg.Current(); current != len(migrations) {
+		t.Errorf("exp %d, got %d", len(migrations), current)
+	}
+}
+
+func TestMigrateChecks(t *testing.T) {
+	changed := slices.Clone(testMigrations)
+	changed[0].Up = `CREATE TABLE one (id INTEGER PRIMARY KEY, name TEXT);`
+	reformatted := slices.Clone(testMigrations)
+	reformatted[0].Up = "CREATE TABLE one\n\t(id INTEGER  PRIMARY KEY);\n"
+	irreversible := slices.Clone(testMigrations)
+	irreversible[1].Down = ""
+
+	for _, tc := range []struct {
+		name       string
+		migrations []Migration
+		version    int
+		exp        error
+		modified   bool
+	}{
+		{name: "same", migrations: testMigrations, version: 2},
+		{name: "reformatted", migrations: reformatted, version: 2},
+		{name: "changed", migrations: changed, version: 2, exp: ErrIncompatibleSQLMigration, modified: true},
+		{name: "missing", migrations: testMigrations[:1], version: 1, exp: ErrNotEnoughSQLMigrations},
+		{name: "irreversible", migrations: irreversible, version: 1, exp: ErrIrreversibleMigration},
+		{name: "unknown version", migrations: testMigrations, version: 3, exp: ErrUnknownMigrationVersion},
+		{name: "negative version", migrations: testMigrations, version: -1, exp: ErrUnknownMigrationVersion},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestSQLite(t)
+			if err := newMigrator(db.db, sqliteDialect, testMigrations).Up(); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+
+			mg := newMigrator(db.db, sqliteDialect, tc.migrations)
+			if err := mg.To(tc.version); !errors.Is(err, tc.exp) {
+				t.Errorf("exp %v, got %v", tc.exp, err)
+			}
+			if tc.exp == ErrNotEnoughSQLMigrations {
+				return
+			}
+			status, err := mg.Status()
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if status[0].Modified != tc.modified {
+				t.Errorf("exp %v, got %v", tc.modified, status[0].Modified)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		legacy     []string
+		migrations []Migration
+		exp        error
+		current    int
+	}{
+		{
+			name:       "all",
+			legacy:     []string{testMigrations[0].Up, testMigrations[1].Up},
+			migrations: testMigrations,
+			current:    2,
+		},
+		{
+			name:       "some",
+			legacy:     []string{testMigrations[0].Up},
+			migrations: testMigrations,
+			current:    1,
+		},
+		{
+			name:       "reformatted",
+			legacy:     []string{"CREATE TABLE one\n  (id INTEGER PRIMARY KEY);\n"},
+			migrations: testMigrations,
+			current:    1,
+		},
+		{
+			name:       "different",
+			legacy:     []string{`CREATE TABLE other (id INTEGER PRIMARY KEY);`},
+			migrations: testMigrations,
+			exp:        ErrIncompatibleSQLMigration,
+		},
+		{
+			name:       "too many",
+			legacy:     []string{testMigrations[0].Up, testMigrations[1].Up, `CREATE TABLE three (id INTEGER);`},
+			migrations: testMigrations,
+			exp:        ErrNotEnoughSQLMigrations,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestSQLite(t)
+			if _, err := db.db.Exec(`CREATE TABLE migration (id INTEGER PRIMARY KEY AUTOINCREMENT, query TEXT NOT NULL)`); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			for _, query := range tc.legacy {
+				if _, err := db.db.Exec(`INSERT INTO migration (query) VALUES (?)`, query); err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+			}
+
+			mg := newMigrator(db.db, sqliteDialect, tc.migrations)
+			current, err := mg.Current()
+			if !errors.Is(err, tc.exp) {
+				t.Fatalf("exp %v, got %v", tc.exp, err)
+			}
+			if tc.exp != nil {
+				return
+			}
+			if current != tc.current {
+				t.Errorf("exp %d, got %d", tc.current, current)
+			}
+			var legacy int
+			if err := db.db.QueryRow(sqliteDialect.tableExists, "migration").Scan(&legacy); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if legacy != 0 {
+				t.Errorf("exp the legacy table to be gone")
+			}
+			if err := mg.Up(); err != nil {
+				t.Errorf("exp nil, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateDirectors(t *testing.T) {
+	ctx := testContext(t)
+	db := newTestSQLite(t)
+	mg := db.Migrator()
+	// the version before people were stored on their own
+	if err := mg.To(3); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	for _, query := range []string{
+		`INSERT INTO movie (id, title, directors) VALUES ('two', 'A', 'Akira Kurosawa, Ishirō Honda')`,
+		`INSERT INTO movie (id, title, directors) VALUES ('twice', 'B', 'Akira Kurosawa,Akira Kurosawa')`,
+		`INSERT INTO movie (id, title, directors) VALUES ('none', 'C', '')`,
+	} {
+		if _, err := db.db.Exec(query); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	if err := mg.Up(); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	kurosawa, honda := Person{TMDBID: -1, Name: "Akira Kurosawa"}, Person{TMDBID: -2, Name: "Ishirō Honda"}
+	movies := NewSQLMovieRepository(db)
+	for id, exp := range map[string][]Person{
+		"two":   {kurosawa, honda},
+		"twice": {kurosawa},
+		"none":  {},
+	} {
+		m, err := movies.FindOne(ctx, id)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if !slices.Equal(m.Directors, exp) {
+			t.Errorf("%s: exp %v, got %v", id, exp, m.Directors)
+		}
+	}
+	found, err := movies.FindByPerson(ctx, kurosawa.TMDBID, RoleDirector)
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if len(found) != 2 {
+		t.Errorf("exp 2 movies by %s, got %d", kurosawa.Name, len(found))
+	}
+
+	// going back before the text column was dropped fills it again
+	if err := mg.To(mg.Latest() - 1); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	var directors string
+	if err := db.db.QueryRow(`SELECT directors FROM movie WHERE id = 'two'`).Scan(&directors); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if directors != "Akira Kurosawa,Ishirō Honda" {
+		t.Errorf("exp the directors as text, got %q", directors)
+	}
+}
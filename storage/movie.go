@@ -1,111 +1,226 @@
 package storage
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"slices"
 	"strings"
+	"time"
+)
+
+// Status tells whether a movie was seen. Movies on the watchlist are
+// not seen yet.
+type Status string
 
-	"github.com/google/uuid"
+const (
+	StatusWantToWatch Status = "want-to-watch"
+	StatusWatched     Status = "watched"
+	StatusAbandoned   Status = "abandoned"
 )
 
 type Movie struct {
-	ID           string   `json:"id"`
-	TMDBID       int64    `json:"tmdbID"`
-	IMDBID       string   `json:"imdbID"`
-	Title        string   `json:"title"`
-	EnglishTitle string   `json:"englishTitle"`
-	Year         int      `json:"year"`
-	Directors    []string `json:"directors"`
-	WatchedOn    string   `json:"watchedOn"`
-	Rating       int      `json:"rating"`
-	Summary      string   `json:"summary"`
-	Comment      string   `json:"comment"`
+	ID               string   `json:"id"`
+	TMDBID           int64    `json:"tmdbID"`
+	IMDBID           string   `json:"imdbID"`
+	Title            string   `json:"title"`
+	EnglishTitle     string   `json:"englishTitle"`
+	Year             int      `json:"year"`
+	Directors        []Person `json:"directors"`
+	Crew             []Credit `json:"crew"`
+	Cast             []Credit `json:"cast"`
+	WatchedOn        Date     `json:"watchedOn"`
+	Rating           int      `json:"rating"`
+	Summary          string   `json:"summary"`
+	Comment          string   `json:"comment"`
+	Status           Status   `json:"status"`
+	Priority         int      `json:"priority"`
+	RecommendedBy    string   `json:"recommendedBy"`
+	Tags             []string `json:"tags"`
+	Runtime          int      `json:"runtime"` // in minutes
+	Genres           []string `json:"genres"`
+	Countries        []string `json:"countries"` // names of the production countries
+	OriginalLanguage string   `json:"originalLanguage"`
+	Tagline          string   `json:"tagline"`
+	VoteAverage      float64  `json:"voteAverage"`
+	VoteCount        int      `json:"voteCount"`
+	PosterPath       string   `json:"posterPath"` // path of the image at TMDB
+	BackdropPath     string   `json:"backdropPath"`
+	// DeletedAt is set when the movie is in the trash.
+	DeletedAt time.Time `json:"deletedAt"`
+	// Version goes up with every change. Store only accepts a movie with
+	// the version that is stored, so changes are not lost.
+	Version int `json:"version"`
 }
 
-type MovieRepository struct {
-	db *Postgres
+// People returns everyone credited with the role, in credit order.
+func (m Movie) People(role Role) []Person {
+	if role == RoleDirector {
+		return m.Directors
+	}
+
+	credits := m.Crew
+	if role == RoleCast {
+		credits = m.Cast
+	}
+	people := make([]Person, 0)
+	for _, c := range credits {
+		if c.Role == role {
+			people = append(people, c.Person)
+		}
+	}
+
+	return people
 }
 
-func NewMovieRepository(db *Postgres) *MovieRepository {
-	return &MovieRepository{
-		db: db,
+// Credits returns all credits of the movie, including the directors.
+func (m Movie) Credits() []Credit {
+	credits := make([]Credit, 0, len(m.Directors)+len(m.Crew)+len(m.Cast))
+	for _, d := range m.Directors {
+		credits = append(credits, Credit{Person: d, Role: RoleDirector, Job: "Director"})
 	}
+	credits = append(credits, m.Crew...)
+	credits = append(credits, m.Cast...)
+
+	return credits
 }
 
-func (mr *MovieRepository) Store(m Movie) error {
-	if m.ID == "" {
-		m.ID = uuid.New().String()
-	}
-
-	directors := strings.Join(m.Directors, ",")
-	if _, err := mr.db.Exec(`INSERT INTO movie (id, tmdb_id, imdb_id, title, english_title, year, directors, summary, watched_on, rating, comment) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-ON CONFLICT (id) DO UPDATE 
-SET 
-  tmdb_id = EXCLUDED.tmdb_id, 
-  imdb_id = EXCLUDED.imdb_id, 
-  title = EXCLUDED.title, 
-  english_title = EXCLUDED.english_title, 
-  year = EXCLUDED.year, 
-  directors = EXCLUDED.directors, 
-  summary = EXCLUDED.summary, 
-  watched_on = EXCLUDED.watched_on, 
-  rating = EXCLUDED.rating, 
-  comment = EXCLUDED.comment;`,
-		m.ID, m.TMDBID, m.IMDBID, m.Title, m.EnglishTitle, m.Year, directors, m.Summary, m.WatchedOn, m.Rating, m.Comment); err != nil {
-		return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-	}
-
-	return nil
+// ParseTags reads a comma separated list of tags.
+func ParseTags(s string) []string {
+	return normalizeTags(strings.Split(s, ","))
 }
 
-func (mr *MovieRepository) Delete(id string) error {
-	if _, err := mr.db.Exec(`DELETE FROM movie WHERE id=$1`, id); err != nil {
-		return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
+// normalizeTags lowercases the tags, sorts them and removes empty and double ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || slices.Contains(normalized, t) {
+			continue
+		}
+		normalized = append(normalized, t)
 	}
+	slices.Sort(normalized)
 
-	return nil
+	return normalized
 }
 
-func (mr *MovieRepository) FindOne(id string) (Movie, error) {
-	row := mr.db.QueryRow(`
-SELECT id, tmdb_id, imdb_id, title, english_title, year, directors, summary, watched_on, rating, comment
-FROM movie
-WHERE id=$1`, id)
-	if row.Err() != nil {
-		return Movie{}, row.Err()
+// normalize makes sure a movie looks the same before and after a round trip
+// through the database.
+func (m Movie) normalize() Movie {
+	if m.Status == "" {
+		m.Status = StatusWatched
 	}
-
-	m := Movie{
-		ID: id,
+	if m.Directors == nil {
+		m.Directors = make([]Person, 0)
+	}
+	if m.Crew == nil {
+		m.Crew = make([]Credit, 0)
+	}
+	if m.Cast == nil {
+		m.Cast = make([]Credit, 0)
+	}
+	if m.Genres == nil {
+		m.Genres = make([]string, 0)
 	}
-	var directors string
-	if err := row.Scan(&m.ID, &m.TMDBID, &m.IMDBID, &m.Title, &m.EnglishTitle, &m.Year, &directors, &m.Summary, &m.WatchedOn, &m.Rating, &m.Comment); err != nil {
-		return Movie{}, fmt.Errorf("%w: %w", ErrPostgresqlFailure, err)
+	if m.Countries == nil {
+		m.Countries = make([]string, 0)
 	}
-	m.Directors = strings.Split(directors, ",")
+	m.Tags = normalizeTags(m.Tags)
 
-	return m, nil
+	return m
 }
 
-func (mr *MovieRepository) FindAll() ([]Movie, error) {
-	rows, err := mr.db.Query(`
-SELECT id, tmdb_id, imdb_id, title, english_title, year, directors, summary, watched_on, rating, comment
-FROM movie`)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-	}
-
-	movies := make([]Movie, 0)
-	defer rows.Close()
-	for rows.Next() {
-		m := Movie{}
-		var directors string
-		if err := rows.Scan(&m.ID, &m.TMDBID, &m.IMDBID, &m.Title, &m.EnglishTitle, &m.Year, &directors, &m.Summary, &m.WatchedOn, &m.Rating, &m.Comment); err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
+// ApplyTMDB copies the data that comes from TMDB into the movie. What is
+// personal, like the watch date, rating, comment, status and tags, is left
+// alone. Fields that TMDB leaves empty do not wipe what is already known.
+func (m *Movie) ApplyTMDB(tm Movie) {
+	setString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
 		}
-		m.Directors = strings.Split(directors, ",")
-		movies = append(movies, m)
 	}
+	setString(&m.Title, tm.Title)
+	setString(&m.EnglishTitle, tm.EnglishTitle)
+	setString(&m.IMDBID, tm.IMDBID)
+	setString(&m.Summary, tm.Summary)
+	setString(&m.OriginalLanguage, tm.OriginalLanguage)
+	setString(&m.Tagline, tm.Tagline)
+	setString(&m.PosterPath, tm.PosterPath)
+	setString(&m.BackdropPath, tm.BackdropPath)
+	if tm.Year != 0 {
+		m.Year = tm.Year
+	}
+	if tm.Runtime != 0 {
+		m.Runtime = tm.Runtime
+	}
+	if tm.VoteCount != 0 {
+		m.VoteAverage = tm.VoteAverage
+		m.VoteCount = tm.VoteCount
+	}
+	if len(tm.Directors) > 0 {
+		m.Directors = tm.Directors
+	}
+	if len(tm.Crew) > 0 {
+		m.Crew = tm.Crew
+	}
+	if len(tm.Cast) > 0 {
+		m.Cast = tm.Cast
+	}
+	if len(tm.Genres) > 0 {
+		m.Genres = tm.Genres
+	}
+	if len(tm.Countries) > 0 {
+		m.Countries = tm.Countries
+	}
+}
+
+type MovieRepository interface {
+	// Store fails with ErrOutdated when the movie was changed since it was
+	// read.
+	Store(ctx context.Context, m Movie) error
+	// Delete moves the movie, its reviews and its viewings to the trash. It
+	// fails with ErrNotFound when there is no such movie.
+	Delete(ctx context.Context, id string) error
+	// Restore takes the movie out of the trash, together with its reviews
+	// and viewings, also the reviews that were deleted before the movie.
+	// It fails with ErrNotFound when there is no such movie.
+	Restore(ctx context.Context, id string) error
+	// FindOne also finds movies that are in the trash.
+	FindOne(ctx context.Context, id string) (Movie, error)
+	FindAll(ctx context.Context) ([]Movie, error)
+	FindByPerson(ctx context.Context, personID int64, role Role) ([]Movie, error)
+	// FindByStatus returns the movies with the highest priority first.
+	FindByStatus(ctx context.Context, status Status) ([]Movie, error)
+	// Query returns a page of the movies that match the query.
+	Query(ctx context.Context, q MovieQuery) (MoviePage, error)
+	// Search returns at most limit movies that match the text, the best
+	// match first. All words must be in the titles, summary and comment of
+	// the movie together, or in one of its reviews. Only movies with one of
+	// the statuses are searched, or all movies if there are none.
+	Search(ctx context.Context, text string, statuses []Status, limit int) ([]SearchHit, error)
+	// FindDeleted returns the trash, the most recently deleted first.
+	FindDeleted(ctx context.Context) ([]Movie, error)
+	// Purge permanently removes everything that was moved to the trash
+	// before the given time and returns the number of movies removed.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
 
-	return movies, nil
+// maxUpdateAttempts is how often UpdateMovie and UpdateReview try to store a
+// change before they give up.
+const maxUpdateAttempts = 3
+
+// UpdateMovie applies the change to the movie and stores it. When the movie
+// was changed by someone else since it was read, it is read again and the
+// change is applied to the new version.
+func UpdateMovie(ctx context.Context, movieRepo MovieRepository, m Movie, change func(m *Movie)) error {
+	for attempt := 1; ; attempt++ {
+		change(&m)
+		err := movieRepo.Store(ctx, m)
+		if !errors.Is(err, ErrOutdated) || attempt == maxUpdateAttempts {
+			return err
+		}
+		if m, err = movieRepo.FindOne(ctx, m.ID); err != nil {
+			return err
+		}
+	}
 }
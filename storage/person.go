@@ -0,0 +1,43 @@
+package storage
+
+type Role string
+
+const (
+	RoleDirector        Role = "director"
+	RoleWriter          Role = "writer"
+	RoleCinematographer Role = "cinematographer"
+	RoleComposer        Role = "composer"
+	RoleEditor          Role = "editor"
+	RoleCast            Role = "cast"
+)
+
+var CrewRoles = []Role{
+	RoleWriter,
+	RoleCinematographer,
+	RoleComposer,
+	RoleEditor,
+}
+
+// Person is someone that worked on a movie, as known by TMDB.
+type Person struct {
+	TMDBID int64  `json:"tmdbID"`
+	Name   string `json:"name"`
+}
+
+// Credit is what a person did on a movie. Job is the description TMDB uses,
+// like "Screenplay" or "Novel" for a writer, Character is only set for cast.
+type Credit struct {
+	Person    Person `json:"person"`
+	Role      Role   `json:"role"`
+	Job       string `json:"job,omitempty"`
+	Character string `json:"character,omitempty"`
+}
+
+func PersonNames(people []Person) []string {
+	names := make([]string, 0, len(people))
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
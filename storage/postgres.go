@@ -3,21 +3,18 @@ package storage
 import (
 	"database/sql"
 	"errors"
-	"fmt"
 
 	_ "github.com/lib/pq"
 )
 
 var (
-	ErrPostgresqlFailure        = errors.New("postgresql failure")
-	ErrNotEnoughSQLMigrations   = errors.New("not enough sql migrations")
-	ErrIncompatibleSQLMigration = errors.New("incompatible sql migration")
+	ErrPostgresqlFailure = errors.New("postgresql failure")
 )
 
-type migration string
-
-var migrations = []migration{
-	`CREATE TABLE movie (
+var migrations = []Migration{
+	{
+		Version: 1,
+		Up: `CREATE TABLE movie (
 	"id" TEXT UNIQUE NOT NULL,
 	"imdb_id" TEXT NOT NULL DEFAULT '',
 	"title" TEXT NOT NULL DEFAULT '',
@@ -30,7 +27,11 @@ var migrations = []migration{
 	"tmdb_id" INTEGER NOT NULL DEFAULT 0,
 	"summary" TEXT NOT NULL DEFAULT ''
 	);`,
-	`CREATE TABLE movie_new (
+		Down: `DROP TABLE movie;`,
+	},
+	{
+		Version: 2,
+		Up: `CREATE TABLE movie_new (
 	"id" TEXT UNIQUE NOT NULL,
 	"imdb_id" TEXT UNIQUE NOT NULL DEFAULT '',
 	"tmdb_id" INTEGER UNIQUE NOT NULL DEFAULT 0,
@@ -43,8 +44,16 @@ var migrations = []migration{
 	"rating" INTEGER NOT NULL DEFAULT 0,
 	"comment" TEXT NOT NULL DEFAULT ''
 	);`,
-	`CREATE TABLE system ("latest_sync" INTEGER);`,
-	`CREATE TABLE review (
+		Down: `DROP TABLE movie_new;`,
+	},
+	{
+		Version: 3,
+		Up:      `CREATE TABLE system ("latest_sync" INTEGER);`,
+		Down:    `DROP TABLE system;`,
+	},
+	{
+		Version: 4,
+		Up: `CREATE TABLE review (
 	"id" TEXT UNIQUE NOT NULL,
 	"movie_id" TEXT NOT NULL,
 	"source" TEXT NOT NULL DEFAULT '',
@@ -56,7 +65,11 @@ var migrations = []migration{
 	"movie_rating" INTEGER NOT NULL DEFAULT 0,
 	"mentioned_titles" JSONB NOT NULL DEFAULT '[]'
 	);`,
-	`CREATE TABLE job_queue (
+		Down: `DROP TABLE review;`,
+	},
+	{
+		Version: 5,
+		Up: `CREATE TABLE job_queue (
 	"id" SERIAL PRIMARY KEY,
 	"action_id" TEXT NOT NULL,
 	"action" TEXT NOT NULL DEFAULT '',
@@ -64,108 +77,305 @@ var migrations = []migration{
 	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	"updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`,
+		Down: `DROP TABLE job_queue;`,
+	},
+	{
+		Version: 6,
+		Up: `CREATE TABLE person (
+	"tmdb_id" INTEGER PRIMARY KEY,
+	"name" TEXT NOT NULL DEFAULT ''
+	);
+CREATE TABLE movie_person (
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"person_id" INTEGER NOT NULL REFERENCES person ("tmdb_id"),
+	"role" TEXT NOT NULL,
+	"position" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("movie_id", "person_id", "role")
+	);
+CREATE INDEX movie_person_person_id ON movie_person ("person_id", "role");`,
+		Down: `DROP TABLE movie_person;
+DROP TABLE person;`,
+		Convert: backfillDirectors(postgresDialect),
+	},
+	{
+		Version: 7,
+		Up: `ALTER TABLE movie_person ADD COLUMN "job" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie_person ADD COLUMN "character" TEXT NOT NULL DEFAULT '';`,
+		Down: `DELETE FROM movie_person WHERE role <> 'director';
+ALTER TABLE movie_person DROP COLUMN "character";
+ALTER TABLE movie_person DROP COLUMN "job";`,
+	},
+	{
+		Version: 8,
+		Up: `CREATE TABLE viewing (
+	"id" TEXT PRIMARY KEY,
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"watched_on" TEXT NOT NULL DEFAULT '',
+	"rating" INTEGER NOT NULL DEFAULT 0,
+	"notes" TEXT NOT NULL DEFAULT '',
+	"location" TEXT NOT NULL DEFAULT ''
+	);
+CREATE INDEX viewing_movie_id ON viewing ("movie_id");
+INSERT INTO viewing (id, movie_id, watched_on, rating)
+SELECT gen_random_uuid()::TEXT, id, watched_on, rating FROM movie WHERE watched_on <> '';`,
+		Down: `DROP TABLE viewing;`,
+	},
+	{
+		Version: 9,
+		Up: `ALTER TABLE movie RENAME COLUMN watched_on TO watched_on_text;
+ALTER TABLE movie ADD COLUMN watched_on DATE;
+ALTER TABLE viewing RENAME COLUMN watched_on TO watched_on_text;
+ALTER TABLE viewing ADD COLUMN watched_on DATE;
+CREATE TABLE unparsed_date (
+	"table_name" TEXT NOT NULL,
+	"row_id" TEXT NOT NULL,
+	"value" TEXT NOT NULL,
+	PRIMARY KEY ("table_name", "row_id")
+	);`,
+		Down: `ALTER TABLE movie ALTER COLUMN watched_on TYPE TEXT USING COALESCE(TO_CHAR(watched_on, 'YYYY-MM-DD'), '');
+UPDATE movie SET watched_on = u.value FROM unparsed_date u WHERE u.table_name = 'movie' AND u.row_id = movie.id;
+ALTER TABLE movie ALTER COLUMN watched_on SET DEFAULT '';
+ALTER TABLE movie ALTER COLUMN watched_on SET NOT NULL;
+ALTER TABLE viewing ALTER COLUMN watched_on TYPE TEXT USING COALESCE(TO_CHAR(watched_on, 'YYYY-MM-DD'), '');
+UPDATE viewing SET watched_on = u.value FROM unparsed_date u WHERE u.table_name = 'viewing' AND u.row_id = viewing.id;
+ALTER TABLE viewing ALTER COLUMN watched_on SET DEFAULT '';
+ALTER TABLE viewing ALTER COLUMN watched_on SET NOT NULL;
+DROP TABLE unparsed_date;`,
+		Convert: convertWatchedOn(
+			`UPDATE %s SET watched_on = $1 WHERE id = $2`,
+			`INSERT INTO unparsed_date (table_name, row_id, value) VALUES ($1, $2, $3)`,
+		),
+	},
+	{
+		Version: 10,
+		Up: `ALTER TABLE movie ADD COLUMN "status" TEXT NOT NULL DEFAULT 'watched';
+ALTER TABLE movie ADD COLUMN "priority" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "recommended_by" TEXT NOT NULL DEFAULT '';
+CREATE INDEX movie_status ON movie ("status", "priority");`,
+		Down: `DROP INDEX movie_status;
+ALTER TABLE movie DROP COLUMN "recommended_by";
+ALTER TABLE movie DROP COLUMN "priority";
+ALTER TABLE movie DROP COLUMN "status";`,
+	},
+	{
+		Version: 11,
+		Up: `CREATE TABLE movie_tag (
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"tag" TEXT NOT NULL,
+	PRIMARY KEY ("movie_id", "tag")
+	);
+CREATE INDEX movie_tag_tag ON movie_tag ("tag");
+CREATE TABLE list (
+	"id" TEXT PRIMARY KEY,
+	"name" TEXT UNIQUE NOT NULL,
+	"description" TEXT NOT NULL DEFAULT ''
+	);
+CREATE TABLE list_movie (
+	"list_id" TEXT NOT NULL REFERENCES list ("id") ON DELETE CASCADE,
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"position" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("list_id", "movie_id")
+	);`,
+		Down: `DROP TABLE list_movie;
+DROP TABLE list;
+DROP TABLE movie_tag;`,
+	},
+	{
+		Version: 12,
+		Up: `CREATE TABLE audit (
+	"id" SERIAL PRIMARY KEY,
+	"revision" TEXT NOT NULL,
+	"table_name" TEXT NOT NULL,
+	"row_id" TEXT NOT NULL,
+	"field" TEXT NOT NULL,
+	"old_value" TEXT NOT NULL DEFAULT '',
+	"new_value" TEXT NOT NULL DEFAULT '',
+	"actor" TEXT NOT NULL DEFAULT '',
+	"changed_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+CREATE INDEX audit_row ON audit ("table_name", "row_id");`,
+		Down: `DROP TABLE audit;`,
+	},
+	{
+		Version: 13,
+		Up: `ALTER TABLE movie ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE review ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE viewing ADD COLUMN "deleted_at" TIMESTAMP;
+CREATE INDEX movie_deleted_at ON movie ("deleted_at");`,
+		Down: `DROP INDEX movie_deleted_at;
+ALTER TABLE viewing DROP COLUMN "deleted_at";
+ALTER TABLE review DROP COLUMN "deleted_at";
+ALTER TABLE movie DROP COLUMN "deleted_at";`,
+	},
+	{
+		Version: 14,
+		Up: `ALTER TABLE movie ADD COLUMN "search" TSVECTOR GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(english_title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(summary, '')), 'B') ||
+	setweight(to_tsvector('english', coalesce(comment, '')), 'B')
+	) STORED;
+ALTER TABLE review ADD COLUMN "search" TSVECTOR GENERATED ALWAYS AS (
+	to_tsvector('english', coalesce(review, ''))
+	) STORED;
+CREATE INDEX movie_search ON movie USING GIN ("search");
+CREATE INDEX review_search ON review USING GIN ("search");`,
+		Down: `DROP INDEX review_search;
+DROP INDEX movie_search;
+ALTER TABLE review DROP COLUMN "search";
+ALTER TABLE movie DROP COLUMN "search";`,
+	},
+	{
+		Version: 15,
+		Up: `ALTER TABLE movie ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE review ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;`,
+		Down: `ALTER TABLE review DROP COLUMN "version";
+ALTER TABLE movie DROP COLUMN "version";`,
+	},
+	{
+		Version: 16,
+		// reviews used to be stored again on every refresh, keep the one
+		// that was rated
+		Up: `DELETE FROM review
+WHERE EXISTS (
+	SELECT 1 FROM review other
+	WHERE other.source = review.source AND other.url = review.url
+	  AND (other.quality > review.quality OR (other.quality = review.quality AND other.id < review.id))
+	);
+ALTER TABLE review ADD COLUMN "vanished_at" TIMESTAMP;
+CREATE UNIQUE INDEX review_source_url ON review ("source", "url");`,
+		Down: `DROP INDEX review_source_url;
+ALTER TABLE review DROP COLUMN "vanished_at";`,
+	},
+	{
+		Version: 17,
+		Up: `ALTER TABLE movie ADD COLUMN "runtime" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "genres" JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE movie ADD COLUMN "countries" JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE movie ADD COLUMN "original_language" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "tagline" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "vote_average" DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "vote_count" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "poster_path" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "backdrop_path" TEXT NOT NULL DEFAULT '';`,
+		Down: `ALTER TABLE movie DROP COLUMN "backdrop_path";
+ALTER TABLE movie DROP COLUMN "poster_path";
+ALTER TABLE movie DROP COLUMN "vote_count";
+ALTER TABLE movie DROP COLUMN "vote_average";
+ALTER TABLE movie DROP COLUMN "tagline";
+ALTER TABLE movie DROP COLUMN "original_language";
+ALTER TABLE movie DROP COLUMN "countries";
+ALTER TABLE movie DROP COLUMN "genres";
+ALTER TABLE movie DROP COLUMN "runtime";`,
+	},
+	{
+		Version: 18,
+		Up: `CREATE TABLE image (
+	"url" TEXT PRIMARY KEY,
+	"hash" TEXT NOT NULL,
+	"file" TEXT NOT NULL,
+	"size" INTEGER NOT NULL DEFAULT 0,
+	"fetched_at" TIMESTAMP NOT NULL
+	);`,
+		Down: `DROP TABLE image;`,
+	},
+	{
+		Version: 19,
+		Up: `ALTER TABLE job_queue ADD COLUMN "worker" TEXT NOT NULL DEFAULT '';
+CREATE INDEX job_queue_status ON job_queue ("status", "id");`,
+		Down: `DROP INDEX job_queue_status;
+ALTER TABLE job_queue DROP COLUMN "worker";`,
+	},
+	{
+		Version: 20,
+		Up:      `ALTER TABLE job_queue ADD COLUMN "lease_until" TIMESTAMP;`,
+		Down:    `ALTER TABLE job_queue DROP COLUMN "lease_until";`,
+	},
+	{
+		Version: 21,
+		Up: `ALTER TABLE job_queue ADD COLUMN "attempts" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE job_queue ADD COLUMN "not_before" TIMESTAMP;
+ALTER TABLE job_queue ADD COLUMN "last_error" TEXT NOT NULL DEFAULT '';`,
+		Down: `ALTER TABLE job_queue DROP COLUMN "last_error";
+ALTER TABLE job_queue DROP COLUMN "not_before";
+ALTER TABLE job_queue DROP COLUMN "attempts";`,
+	},
+	{
+		Version: 22,
+		Up: `ALTER TABLE job_queue RENAME COLUMN "not_before" TO "run_at";
+CREATE TABLE job_schedule (
+	"id" SERIAL PRIMARY KEY,
+	"name" TEXT NOT NULL UNIQUE,
+	"cron" TEXT NOT NULL,
+	"action" TEXT NOT NULL,
+	"action_id" TEXT NOT NULL DEFAULT '',
+	"next_run" TIMESTAMP NOT NULL,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+		Down: `DROP TABLE job_schedule;
+ALTER TABLE job_queue RENAME COLUMN "run_at" TO "not_before";`,
+	},
+	{
+		Version: 23,
+		Up: `ALTER TABLE job_queue ADD COLUMN "priority" INTEGER NOT NULL DEFAULT 0;
+DROP INDEX job_queue_status;
+CREATE INDEX job_queue_next ON job_queue ("status", "priority" DESC, "id");`,
+		Down: `DROP INDEX job_queue_next;
+CREATE INDEX job_queue_status ON job_queue ("status", "id");
+ALTER TABLE job_queue DROP COLUMN "priority";`,
+	},
+	{
+		Version: 24,
+		Up:      `ALTER TABLE job_schedule ADD COLUMN "timezone" TEXT NOT NULL DEFAULT 'UTC';`,
+		Down:    `ALTER TABLE job_schedule DROP COLUMN "timezone";`,
+	},
+	{
+		Version: 25,
+		// the directors are in movie_person since version 6
+		Up: `ALTER TABLE movie DROP COLUMN "directors";`,
+		Down: `ALTER TABLE movie ADD COLUMN "directors" TEXT NOT NULL DEFAULT '';
+UPDATE movie SET "directors" = COALESCE((
+	SELECT string_agg(p.name, ',' ORDER BY mp.position)
+	FROM movie_person mp JOIN person p ON p.tmdb_id = mp.person_id
+	WHERE mp.movie_id = movie.id AND mp.role = 'director'), '');`,
+	},
 }
 
-type Postgres struct {
-	db *sql.DB
+var postgresDialect = dialect{
+	name:        TypePostgres,
+	numbered:    true,
+	failure:     ErrPostgresqlFailure,
+	classify:    postgresError,
+	tableExists: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`,
+	like:        "ILIKE",
+	watchedOn:   "COALESCE(watched_on::TEXT, '')",
+	noLimit:     "ALL",
+	fullText:    true,
 }
 
-func NewPostgres(connStr string) (*Postgres, error) {
-	db, err := sql.Open("postgres", connStr)
+func NewPostgres(connStr string) (*SQL, error) {
+	pg, err := openPostgres(connStr)
 	if err != nil {
 		return nil, err
 	}
 
-	pg := &Postgres{
-		db: db,
-	}
-
-	if err := pg.migrate(migrations); err != nil {
-		return &Postgres{}, err
+	if err := pg.Migrator().Up(); err != nil {
+		return &SQL{}, err
 	}
 
 	return pg, nil
 }
 
-func (pg *Postgres) migrate(wanted []migration) error {
-	// admin table
-	if _, err := pg.db.Exec(`
-CREATE TABLE IF NOT EXISTS migration
-(
-    id SERIAL PRIMARY KEY, 
-    query TEXT
-)`); err != nil {
-		return err
-	}
-
-	// find existing
-	rows, err := pg.db.Query(`SELECT query FROM migration ORDER BY id`)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-	}
-
-	existing := []migration{}
-	for rows.Next() {
-		var query string
-		if err := rows.Scan(&query); err != nil {
-			return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-		}
-		existing = append(existing, migration(query))
-	}
-	rows.Close()
-
-	// compare
-	missing, err := compareMigrations(wanted, existing)
+func openPostgres(connStr string) (*SQL, error) {
+	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-	}
-
-	// execute missing
-	for _, query := range missing {
-		if _, err := pg.db.Exec(string(query)); err != nil {
-			return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-		}
-
-		// register
-		if _, err := pg.db.Exec(`
-INSERT INTO migration
-(query) VALUES ($1)
-`, query); err != nil {
-			return fmt.Errorf("%w: %v", ErrPostgresqlFailure, err)
-		}
-	}
-
-	return nil
-}
-
-func (pg *Postgres) Exec(query string, args ...any) (sql.Result, error) {
-	return pg.db.Exec(query, args...)
-}
-
-func (pg *Postgres) QueryRow(query string, args ...any) *sql.Row {
-	return pg.db.QueryRow(query, args...)
-}
-
-func (pg *Postgres) Query(query string, args ...any) (*sql.Rows, error) {
-	return pg.db.Query(query, args...)
-}
-
-func compareMigrations(wanted, existing []migration) ([]migration, error) {
-	needed := []migration{}
-	if len(wanted) < len(existing) {
-		return []migration{}, ErrNotEnoughSQLMigrations
-	}
-
-	for i, want := range wanted {
-		switch {
-		case i >= len(existing):
-			needed = append(needed, want)
-		case want == existing[i]:
-			// do nothing
-		case want != existing[i]:
-			return []migration{}, fmt.Errorf("%w: %v", ErrIncompatibleSQLMigration, want)
-		}
+		return nil, err
 	}
 
-	return needed, nil
+	return &SQL{
+		db:         db,
+		actor:      defaultActor(),
+		dialect:    postgresDialect,
+		migrations: migrations,
+	}, nil
 }
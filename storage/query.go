@@ -0,0 +1,387 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type SortField string
+
+const (
+	SortTitle     SortField = "title"
+	SortYear      SortField = "year"
+	SortRating    SortField = "rating"
+	SortWatchedOn SortField = "watched_on"
+	SortPriority  SortField = "priority"
+)
+
+var (
+	SortFields = []SortField{SortTitle, SortYear, SortRating, SortWatchedOn, SortPriority}
+
+	ErrInvalidQuery = fmt.Errorf("%w query", ErrValidation)
+)
+
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+// MovieQuery selects, orders and pages movies. Fields that are left empty do
+// not filter anything. Movies in the trash are never included.
+type MovieQuery struct {
+	Statuses   []Status
+	YearFrom   int
+	YearTo     int
+	RatingFrom int
+	RatingTo   int
+	// Director is part of the name of one of the directors.
+	Director    string
+	WatchedFrom Date
+	WatchedTo   Date
+	// Text is part of the title, the English title, the summary or the
+	// comment.
+	Text string
+	// Sort defaults to the title. Movies that sort the same are ordered by
+	// id, so pages are stable.
+	Sort   []Sort
+	Limit  int
+	Offset int
+	// Cursor continues after the last movie of a previous page. It is used
+	// instead of Offset.
+	Cursor string
+}
+
+// MoviePage is the result of a query. Total counts all matching movies, not
+// only the ones on the page. Next is the cursor for the following page and
+// is empty on the last one.
+type MoviePage struct {
+	Movies []Movie
+	Total  int
+	Next   string
+}
+
+// QueryAll runs the query page by page, with at most size movies per page,
+// and returns the movies of all pages. Limit and Cursor of q are ignored.
+// It is for exports, that need every movie. The clients show one page at a
+// time and follow the cursor when more is needed.
+func QueryAll(ctx context.Context, movieRepo MovieRepository, q MovieQuery, size int) ([]Movie, error) {
+	q.Limit, q.Offset, q.Cursor = size, 0, ""
+	movies := make([]Movie, 0)
+	for {
+		page, err := movieRepo.Query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, page.Movies...)
+		if page.Next == "" {
+			return movies, nil
+		}
+		q.Cursor = page.Next
+	}
+}
+
+func (q MovieQuery) sort() []Sort {
+	if len(q.Sort) == 0 {
+		return []Sort{{Field: SortTitle}}
+	}
+	return q.Sort
+}
+
+func (q MovieQuery) validate() error {
+	for _, s := range q.Sort {
+		if !slices.Contains(SortFields, s.Field) {
+			return fmt.Errorf("%w: unknown sort field %s", ErrInvalidQuery, s.Field)
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("%w: negative limit or offset", ErrInvalidQuery)
+	}
+	if q.Cursor != "" && q.Offset > 0 {
+		return fmt.Errorf("%w: both a cursor and an offset", ErrInvalidQuery)
+	}
+
+	return nil
+}
+
+// match tells whether the movie passes the filters. The database backends
+// express the same in SQL.
+func (q MovieQuery) match(m Movie) bool {
+	contains := func(s, sub string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+	}
+
+	switch {
+	case !m.DeletedAt.IsZero():
+		return false
+	case len(q.Statuses) > 0 && !slices.Contains(q.Statuses, m.Status):
+		return false
+	case q.YearFrom != 0 && m.Year < q.YearFrom,
+		q.YearTo != 0 && m.Year > q.YearTo:
+		return false
+	case q.RatingFrom != 0 && m.Rating < q.RatingFrom,
+		q.RatingTo != 0 && m.Rating > q.RatingTo:
+		return false
+	case !q.WatchedFrom.IsZero() && (m.WatchedOn.IsZero() || m.WatchedOn.Before(q.WatchedFrom)),
+		!q.WatchedTo.IsZero() && (m.WatchedOn.IsZero() || m.WatchedOn.After(q.WatchedTo)):
+		return false
+	}
+	if q.Director != "" && !slices.ContainsFunc(m.Directors, func(p Person) bool { return contains(p.Name, q.Director) }) {
+		return false
+	}
+	if q.Text != "" && !contains(m.Title, q.Text) && !contains(m.EnglishTitle, q.Text) &&
+		!contains(m.Summary, q.Text) && !contains(m.Comment, q.Text) {
+		return false
+	}
+
+	return true
+}
+
+// sortValues are the values the movie is sorted on, followed by the id.
+func sortValues(m Movie, sorts []Sort) []any {
+	values := make([]any, 0, len(sorts)+1)
+	for _, s := range sorts {
+		switch s.Field {
+		case SortTitle:
+			values = append(values, m.Title)
+		case SortYear:
+			values = append(values, m.Year)
+		case SortRating:
+			values = append(values, m.Rating)
+		case SortWatchedOn:
+			// movies without a watch date come before all others
+			values = append(values, m.WatchedOn.String())
+		case SortPriority:
+			values = append(values, m.Priority)
+		}
+	}
+
+	return append(values, m.ID)
+}
+
+func compareValues(a, b []any, sorts []Sort) int {
+	for i := range a {
+		var c int
+		switch av := a[i].(type) {
+		case int:
+			c = av - b[i].(int)
+		case string:
+			c = strings.Compare(av, b[i].(string))
+		}
+		if c == 0 {
+			continue
+		}
+		if i < len(sorts) && sorts[i].Desc {
+			c = -c
+		}
+		return c
+	}
+
+	return 0
+}
+
+func encodeCursor(values []any) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the values of the cursor with the same types as
+// sortValues.
+func decodeCursor(cursor string, sorts []Sort) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	raw := make([]any, 0)
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) != len(sorts)+1 {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	values := make([]any, len(raw))
+	for i, v := range raw {
+		numeric := i < len(sorts) && (sorts[i].Field == SortYear || sorts[i].Field == SortRating || sorts[i].Field == SortPriority)
+		switch v := v.(type) {
+		case float64:
+			if !numeric {
+				return nil, fmt.Errorf("%w: cursor does not match the sort order", ErrInvalidQuery)
+			}
+			values[i] = int(v)
+		case string:
+			if numeric {
+				return nil, fmt.Errorf("%w: cursor does not match the sort order", ErrInvalidQuery)
+			}
+			values[i] = v
+		default:
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+	}
+
+	return values, nil
+}
+
+// queryMovies applies the query to a slice of movies.
+func queryMovies(movies []Movie, q MovieQuery) (MoviePage, error) {
+	if err := q.validate(); err != nil {
+		return MoviePage{}, err
+	}
+	sorts := q.sort()
+	var after []any
+	if q.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(q.Cursor, sorts); err != nil {
+			return MoviePage{}, err
+		}
+	}
+
+	matched := make([]Movie, 0)
+	for _, m := range movies {
+		if q.match(m) {
+			matched = append(matched, m)
+		}
+	}
+	slices.SortFunc(matched, func(a, b Movie) int {
+		return compareValues(sortValues(a, sorts), sortValues(b, sorts), sorts)
+	})
+
+	rest := matched
+	if after != nil {
+		i := 0
+		for i < len(rest) && compareValues(sortValues(rest[i], sorts), after, sorts) <= 0 {
+			i++
+		}
+		rest = rest[i:]
+	}
+	rest = rest[min(q.Offset, len(rest)):]
+	if q.Limit > 0 {
+		rest = rest[:min(q.Limit+1, len(rest))]
+	}
+
+	return newMoviePage(rest, len(matched), q)
+}
+
+// movieSQL builds the query for a page of movies, that selects the given
+// columns, and a query that counts all matches. The page query asks for
+// one movie more than the limit, to find out whether there is a next page.
+func (d dialect) movieSQL(q MovieQuery, columns string) (string, []any, string, []any, error) {
+	if err := q.validate(); err != nil {
+		return "", nil, "", nil, err
+	}
+	sorts := q.sort()
+
+	args := make([]any, 0)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "?"
+	}
+	where := []string{"deleted_at IS NULL"}
+	if len(q.Statuses) > 0 {
+		phs := make([]string, 0, len(q.Statuses))
+		for _, s := range q.Statuses {
+			phs = append(phs, arg(s))
+		}
+		where = append(where, fmt.Sprintf("status IN (%s)", strings.Join(phs, ", ")))
+	}
+	for _, c := range []struct {
+		value int
+		cond  string
+	}{
+		{q.YearFrom, "year >= %s"},
+		{q.YearTo, "year <= %s"},
+		{q.RatingFrom, "rating >= %s"},
+		{q.RatingTo, "rating <= %s"},
+	} {
+		if c.value != 0 {
+			where = append(where, fmt.Sprintf(c.cond, arg(c.value)))
+		}
+	}
+	if !q.WatchedFrom.IsZero() {
+		where = append(where, fmt.Sprintf("watched_on >= %s", arg(q.WatchedFrom)))
+	}
+	if !q.WatchedTo.IsZero() {
+		where = append(where, fmt.Sprintf("watched_on <= %s", arg(q.WatchedTo)))
+	}
+	if q.Director != "" {
+		where = append(where, fmt.Sprintf(`id IN (SELECT mp.movie_id FROM movie_person mp JOIN person p ON p.tmdb_id = mp.person_id
+WHERE mp.role = '%s' AND p.name %s %s)`, RoleDirector, d.like, arg("%"+q.Director+"%")))
+	}
+	if q.Text != "" {
+		text := "%" + q.Text + "%"
+		where = append(where, fmt.Sprintf("(title %[1]s %s OR english_title %[1]s %s OR summary %[1]s %s OR comment %[1]s %s)",
+			d.like, arg(text), arg(text), arg(text), arg(text)))
+	}
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM movie WHERE %s", strings.Join(where, " AND "))
+	countArgs := slices.Clone(args)
+
+	exprs := make([]string, 0, len(sorts)+1)
+	order := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		expr := string(s.Field)
+		if s.Field == SortWatchedOn {
+			expr = d.watchedOn
+		}
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		exprs = append(exprs, expr)
+		order = append(order, fmt.Sprintf("%s %s", expr, dir))
+	}
+	exprs = append(exprs, "id")
+	order = append(order, "id ASC")
+
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor, sorts)
+		if err != nil {
+			return "", nil, "", nil, err
+		}
+		// (a > x) OR (a = x AND b > y) OR ...
+		alts := make([]string, 0, len(exprs))
+		for i := range exprs {
+			conds := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				conds = append(conds, fmt.Sprintf("%s = %s", exprs[j], arg(after[j])))
+			}
+			op := ">"
+			if i < len(sorts) && sorts[i].Desc {
+				op = "<"
+			}
+			conds = append(conds, fmt.Sprintf("%s %s %s", exprs[i], op, arg(after[i])))
+			alts = append(alts, fmt.Sprintf("(%s)", strings.Join(conds, " AND ")))
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(alts, " OR ")))
+	}
+
+	limit := d.noLimit
+	if q.Limit > 0 {
+		limit = fmt.Sprintf("%d", q.Limit+1)
+	}
+	pageQuery := fmt.Sprintf("SELECT %s\nFROM movie\nWHERE %s\nORDER BY %s\nLIMIT %s OFFSET %d",
+		columns, strings.Join(where, " AND "), strings.Join(order, ", "), limit, q.Offset)
+
+	return pageQuery, args, countQuery, countArgs, nil
+}
+
+// newMoviePage trims the extra movie that movieSQL asks for and turns it
+// into the cursor for the next page.
+func newMoviePage(movies []Movie, total int, q MovieQuery) (MoviePage, error) {
+	page := MoviePage{
+		Movies: movies,
+		Total:  total,
+	}
+	if q.Limit > 0 && len(movies) > q.Limit {
+		page.Movies = movies[:q.Limit]
+		next, err := encodeCursor(sortValues(page.Movies[q.Limit-1], q.sort()))
+		if err != nil {
+			return MoviePage{}, err
+		}
+		page.Next = next
+	}
+
+	return page, nil
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	check := func(sorts []Sort, values ...any) {
+		t.Helper()
+		cursor, err := encodeCursor(values)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		act, err := decodeCursor(cursor, sorts)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if !slices.Equal(act, values) {
+			t.Errorf("exp %v, got %v", values, act)
+		}
+	}
+
+	check(nil, "id")
+	check([]Sort{{Field: SortTitle}}, "Ran", "id")
+	check([]Sort{{Field: SortYear}, {Field: SortRating, Desc: true}, {Field: SortPriority}}, 1985, 9, 0, "id")
+	// movies without a watch date have an empty one
+	check([]Sort{{Field: SortWatchedOn, Desc: true}}, "", "id")
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(values ...any) string {
+		cursor, err := encodeCursor(values)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		return cursor
+	}
+
+	for _, tc := range []struct {
+		name   string
+		cursor string
+		sorts  []Sort
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "not json", cursor: "bm90IGpzb24"},
+		{name: "too short", cursor: encode("id"), sorts: []Sort{{Field: SortTitle}}},
+		{name: "too long", cursor: encode("Ran", "id")},
+		{name: "number for text", cursor: encode(1985, "id"), sorts: []Sort{{Field: SortTitle}}},
+		{name: "text for number", cursor: encode("1985", "id"), sorts: []Sort{{Field: SortYear}}},
+		{name: "number for id", cursor: encode(12)},
+		{name: "object", cursor: encode(map[string]int{"a": 1})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := decodeCursor(tc.cursor, tc.sorts); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("exp %v, got %v", ErrInvalidQuery, err)
+			}
+		})
+	}
+}
+
+func TestMovieSQL(t *testing.T) {
+	cursor, err := encodeCursor([]any{2001, "id"})
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		dialect   dialect
+		query     MovieQuery
+		expQuery  string
+		expArgs   []any
+		expCount  string
+		countArgs []any
+		expErr    error
+	}{
+		{
+			name:     "all",
+			dialect:  sqliteDialect,
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL\nORDER BY title ASC, id ASC\nLIMIT -1 OFFSET 0",
+			expArgs:  []any{},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL",
+		},
+		{
+			name:    "filters",
+			dialect: sqliteDialect,
+			query: MovieQuery{
+				Statuses:    []Status{StatusWatched, StatusAbandoned},
+				YearFrom:    1950,
+				RatingTo:    8,
+				WatchedFrom: NewDate(2020, time.January, 1),
+				Limit:       10,
+				Offset:      20,
+			},
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL AND status IN (?, ?) AND year >= ? AND rating <= ? AND watched_on >= ?\nORDER BY title ASC, id ASC\nLIMIT 11 OFFSET 20",
+			expArgs:  []any{StatusWatched, StatusAbandoned, 1950, 8, NewDate(2020, time.January, 1)},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL AND status IN (?, ?) AND year >= ? AND rating <= ? AND watched_on >= ?",
+		},
+		{
+			name:     "text in postgres",
+			dialect:  postgresDialect,
+			query:    MovieQuery{Text: "ran"},
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL AND (title ILIKE ? OR english_title ILIKE ? OR summary ILIKE ? OR comment ILIKE ?)\nORDER BY title ASC, id ASC\nLIMIT ALL OFFSET 0",
+			expArgs:  []any{"%ran%", "%ran%", "%ran%", "%ran%"},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL AND (title ILIKE ? OR english_title ILIKE ? OR summary ILIKE ? OR comment ILIKE ?)",
+		},
+		{
+			name:     "director",
+			dialect:  sqliteDialect,
+			query:    MovieQuery{Director: "kuro"},
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL AND id IN (SELECT mp.movie_id FROM movie_person mp JOIN person p ON p.tmdb_id = mp.person_id\nWHERE mp.role = 'director' AND p.name LIKE ?)\nORDER BY title ASC, id ASC\nLIMIT -1 OFFSET 0",
+			expArgs:  []any{"%kuro%"},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL AND id IN (SELECT mp.movie_id FROM movie_person mp JOIN person p ON p.tmdb_id = mp.person_id\nWHERE mp.role = 'director' AND p.name LIKE ?)",
+		},
+		{
+			name:     "sort",
+			dialect:  postgresDialect,
+			query:    MovieQuery{Sort: []Sort{{Field: SortWatchedOn, Desc: true}, {Field: SortTitle}}},
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL\nORDER BY COALESCE(watched_on::TEXT, '') DESC, title ASC, id ASC\nLIMIT ALL OFFSET 0",
+			expArgs:  []any{},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL",
+		},
+		{
+			name:     "cursor",
+			dialect:  sqliteDialect,
+			query:    MovieQuery{Sort: []Sort{{Field: SortYear, Desc: true}}, Limit: 5, Cursor: cursor},
+			expQuery: "SELECT id\nFROM movie\nWHERE deleted_at IS NULL AND ((year < ?) OR (year = ? AND id > ?))\nORDER BY year DESC, id ASC\nLIMIT 6 OFFSET 0",
+			expArgs:  []any{2001, 2001, "id"},
+			expCount: "SELECT COUNT(*) FROM movie WHERE deleted_at IS NULL",
+		},
+		{
+			name:    "unknown sort",
+			dialect: sqliteDialect,
+			query:   MovieQuery{Sort: []Sort{{Field: "length"}}},
+			expErr:  ErrInvalidQuery,
+		},
+		{
+			name:    "negative limit",
+			dialect: sqliteDialect,
+			query:   MovieQuery{Limit: -1},
+			expErr:  ErrInvalidQuery,
+		},
+		{
+			name:    "cursor of other sort",
+			dialect: sqliteDialect,
+			query:   MovieQuery{Sort: []Sort{{Field: SortTitle}}, Cursor: cursor},
+			expErr:  ErrInvalidQuery,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args, count, countArgs, err := tc.dialect.movieSQL(tc.query, "id")
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("exp %v, got %v", tc.expErr, err)
+			}
+			if tc.expErr != nil {
+				return
+			}
+			if query != tc.expQuery {
+				t.Errorf("exp query\n%s\ngot\n%s", tc.expQuery, query)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tc.expArgs) {
+				t.Errorf("exp args %v, got %v", tc.expArgs, args)
+			}
+			if count != tc.expCount {
+				t.Errorf("exp count query\n%s\ngot\n%s", tc.expCount, count)
+			}
+			// the count has the filters, but not the cursor
+			if n := len(tc.expArgs) - len(countArgs); tc.query.Cursor == "" && n != 0 {
+				t.Errorf("exp the count to have the same args, got %v", countArgs)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		dialect dialect
+		query   string
+		exp     string
+	}{
+		{name: "sqlite", dialect: sqliteDialect, query: "SELECT ? WHERE a = ?", exp: "SELECT ? WHERE a = ?"},
+		{name: "postgres", dialect: postgresDialect, query: "SELECT ? WHERE a = ?", exp: "SELECT $1 WHERE a = $2"},
+		{name: "quoted", dialect: postgresDialect, query: "SELECT '?', ? WHERE a = 'it''s?'", exp: "SELECT '?', $1 WHERE a = 'it''s?'"},
+		{name: "none", dialect: postgresDialect, query: "SELECT 1", exp: "SELECT 1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if act := tc.dialect.rebind(tc.query); act != tc.exp {
+				t.Errorf("exp %q, got %q", tc.exp, act)
+			}
+		})
+	}
+}
+
+// TestQueryPages checks that the backends find the same movies in the same
+// order, also when the pages are followed with their cursors.
+func TestQueryPages(t *testing.T) {
+	movies := make([]Movie, 0)
+	for i := 0; i < 23; i++ {
+		m := Movie{
+			ID:     fmt.Sprintf("m%02d", i),
+			Title:  fmt.Sprintf("Movie %d", i%7),
+			Year:   1990 + i%4,
+			Rating: i % 3,
+			Status: StatusWatched,
+		}
+		if i%5 != 0 {
+			m.WatchedOn = NewDate(2020, time.Month(1+i%12), 1)
+		}
+		if i%6 == 0 {
+			m.Status = StatusWantToWatch
+			m.Priority = i % 4
+		}
+		movies = append(movies, m)
+	}
+
+	backends := map[string]Backend{
+		"memory": NewMemory(),
+		"sqlite": newTestSQLiteBackend(t),
+	}
+	for name, b := range backends {
+		for _, m := range movies {
+			if err := b.Movies().Store(testContext(t), m); err != nil {
+				t.Fatalf("%s: exp nil, got %v", name, err)
+			}
+		}
+	}
+
+	for _, q := range []MovieQuery{
+		{},
+		{Sort: []Sort{{Field: SortYear}, {Field: SortRating, Desc: true}}},
+		{Sort: []Sort{{Field: SortWatchedOn, Desc: true}}},
+		{Statuses: []Status{StatusWantToWatch}, Sort: []Sort{{Field: SortPriority, Desc: true}}},
+		{YearFrom: 1991, Sort: []Sort{{Field: SortTitle, Desc: true}}},
+	} {
+		// the memory backend sorts in Go, the others must agree with it
+		all, err := backends["memory"].Movies().Query(testContext(t), q)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		exp := movieIDs(all.Movies)
+		for name, b := range backends {
+			t.Run(fmt.Sprintf("%s %v", name, q.Sort), func(t *testing.T) {
+				page, err := b.Movies().Query(testContext(t), q)
+				if err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+				if act := movieIDs(page.Movies); !slices.Equal(exp, act) {
+					t.Errorf("exp %v, got %v", exp, act)
+				}
+				if page.Total != len(exp) {
+					t.Errorf("exp total %d, got %d", len(exp), page.Total)
+				}
+				paged, err := QueryAll(testContext(t), b.Movies(), q, 4)
+				if err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+				if act := movieIDs(paged); !slices.Equal(exp, act) {
+					t.Errorf("exp %v, got %v", exp, act)
+				}
+			})
+		}
+	}
+}
+
+func movieIDs(movies []Movie) []string {
+	ids := make([]string, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+
+	return ids
+}
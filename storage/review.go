@@ -1,7 +1,9 @@
 package storage
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"time"
 )
 
 const (
@@ -16,6 +18,8 @@ type TitleMentions struct {
 
 type ReviewSource string
 
+// Review is a review by someone else. The source and the URL of the review
+// identify it, there is only one review for each.
 type Review struct {
 	ID          string
 	MovieID     string
@@ -25,207 +29,113 @@ type Review struct {
 	MovieRating int
 	Quality     int
 	Mentions    TitleMentions
+	// VanishedAt is set when the review was no longer found at the source.
+	VanishedAt time.Time
+	// Version goes up with every change, like that of a movie.
+	Version int
 }
 
-type ReviewRepository struct {
-	db *Postgres
+type ReviewRepository interface {
+	// Store fails with ErrOutdated when the review was changed since it was
+	// read.
+	Store(ctx context.Context, r Review) error
+	FindOne(ctx context.Context, id string) (Review, error)
+	FindByMovieID(ctx context.Context, movieID string) ([]Review, error)
+	FindNextUnrated(ctx context.Context) (Review, error)
+	FindUnrated(ctx context.Context) ([]Review, error)
+	FindNextNoTitles(ctx context.Context) (Review, error)
+	FindNoTitles(ctx context.Context) ([]Review, error)
+	FindAll(ctx context.Context) ([]Review, error)
+	// DeleteByMovieID moves the reviews of the movie to the trash. They
+	// come back when the movie is restored.
+	DeleteByMovieID(ctx context.Context, id string) error
 }
 
-func NewReviewRepository(db *Postgres) *ReviewRepository {
-	return &ReviewRepository{
-		db: db,
-	}
-}
-
-func (rr *ReviewRepository) Store(r Review) error {
-	titles, err := json.Marshal(r.Mentions)
-	if err != nil {
-		return err
-	}
-	if _, err := rr.db.Exec(`INSERT INTO review (id, movie_id, source, url, review, movie_rating, quality, mentioned_titles) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
-ON CONFLICT (id) DO UPDATE SET movie_id = EXCLUDED.movie_id, source = EXCLUDED.source, url = EXCLUDED.url, 
-review = EXCLUDED.review, movie_rating = EXCLUDED.movie_rating, quality = EXCLUDED.quality, 
-mentioned_titles = EXCLUDED.mentioned_titles;`,
-		r.ID, r.MovieID, r.Source, r.URL, r.Review, r.MovieRating, r.Quality, titles); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (rr *ReviewRepository) FindOne(id string) (Review, error) {
-	row := rr.db.QueryRow(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE id=$1`, id)
-	if row.Err() != nil {
-		return Review{}, row.Err()
-	}
-
-	r := Review{}
-	var titles string
-	if err := row.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-		return Review{}, err
-	}
-	if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-		return Review{}, err
-	}
-
-	return r, nil
-}
-
-func (rr *ReviewRepository) FindByMovieID(movieID string) ([]Review, error) {
-	rows, err := rr.db.Query(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE movie_id=$1`, movieID)
-	if err != nil {
-		return nil, err
-	}
-
-	reviews := make([]Review, 0)
-	var titles string
-	for rows.Next() {
-		r := Review{}
-		if err := rows.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-			return nil, err
+// UpdateReview applies the change to the review and stores it, like
+// UpdateMovie.
+func UpdateReview(ctx context.Context, reviewRepo ReviewRepository, r Review, change func(r *Review)) error {
+	for attempt := 1; ; attempt++ {
+		change(&r)
+		err := reviewRepo.Store(ctx, r)
+		if !errors.Is(err, ErrOutdated) || attempt == maxUpdateAttempts {
+			return err
 		}
-		if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-			return []Review{}, err
+		if r, err = reviewRepo.FindOne(ctx, r.ID); err != nil {
+			return err
 		}
-		reviews = append(reviews, r)
 	}
-	rows.Close()
-
-	return reviews, nil
 }
 
-func (rr *ReviewRepository) FindNextUnrated() (Review, error) {
-	row := rr.db.QueryRow(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE quality=0 
-LIMIT 1`)
-	if row.Err() != nil {
-		return Review{}, row.Err()
-	}
-
-	r := Review{}
-	var titles string
-	if err := row.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-		return Review{}, err
-	}
-	if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-		return Review{}, err
-	}
-
-	return r, nil
+// ReviewMerge tells what MergeReviews did.
+type ReviewMerge struct {
+	Added    []Review
+	Updated  []Review
+	Vanished []Review
 }
 
-func (rr *ReviewRepository) FindUnrated() ([]Review, error) {
-	rows, err := rr.db.Query(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE quality=0`)
+// MergeReviews brings the stored reviews of the movie in line with the ones
+// that were fetched from the source. Reviews are matched on their source and
+// URL. New ones are added, known ones get the text and rating of the source
+// and stored ones that were not fetched are marked as vanished. The quality
+// and the mentions are left alone. When the same URL is fetched more than
+// once, the first one is used.
+func MergeReviews(ctx context.Context, reviewRepo ReviewRepository, movieID string, source ReviewSource, fetched []Review) (ReviewMerge, error) {
+	stored, err := reviewRepo.FindByMovieID(ctx, movieID)
 	if err != nil {
-		return nil, err
+		return ReviewMerge{}, err
 	}
-
-	reviews := make([]Review, 0)
-	var titles string
-	for rows.Next() {
-		r := Review{}
-		if err := rows.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-			return nil, err
-		}
-		if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-			return []Review{}, err
+	known := make(map[string]Review)
+	for _, r := range stored {
+		if r.Source == source {
+			known[r.URL] = r
 		}
-		reviews = append(reviews, r)
-	}
-	rows.Close()
-
-	return reviews, nil
-}
-
-func (rr *ReviewRepository) FindNextNoTitles() (Review, error) {
-	row := rr.db.QueryRow(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE mentioned_titles='{}' 
-LIMIT 1`)
-	if row.Err() != nil {
-		return Review{}, row.Err()
-	}
-
-	r := Review{}
-	var titles string
-	if err := row.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-		return Review{}, err
 	}
-	if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-		return Review{}, err
-	}
-
-	return r, nil
-}
 
-func (rr *ReviewRepository) FindNoTitles() ([]Review, error) {
-	rows, err := rr.db.Query(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review 
-WHERE mentioned_titles='{}'`)
-	if err != nil {
-		return nil, err
+	merge := ReviewMerge{
+		Added:    make([]Review, 0),
+		Updated:  make([]Review, 0),
+		Vanished: make([]Review, 0),
 	}
-
-	reviews := make([]Review, 0)
-	var titles string
-	for rows.Next() {
-		r := Review{}
-		if err := rows.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-			return nil, err
+	now := time.Now().UTC().Truncate(time.Second)
+	// a source can list the same review twice, only the first one counts
+	seen := make(map[string]bool)
+	for _, f := range fetched {
+		if seen[f.URL] {
+			continue
 		}
-		if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-			return []Review{}, err
+		seen[f.URL] = true
+		r, ok := known[f.URL]
+		delete(known, f.URL)
+		switch {
+		case !ok:
+			f.MovieID, f.Source, f.Version = movieID, source, 0
+			if err := reviewRepo.Store(ctx, f); err != nil {
+				return ReviewMerge{}, err
+			}
+			merge.Added = append(merge.Added, f)
+		case r.Review != f.Review || r.MovieRating != f.MovieRating || !r.VanishedAt.IsZero():
+			refresh := func(r *Review) {
+				r.Review, r.MovieRating, r.VanishedAt = f.Review, f.MovieRating, time.Time{}
+			}
+			if err := UpdateReview(ctx, reviewRepo, r, refresh); err != nil {
+				return ReviewMerge{}, err
+			}
+			refresh(&r)
+			merge.Updated = append(merge.Updated, r)
 		}
-		reviews = append(reviews, r)
-	}
-	rows.Close()
-
-	return reviews, nil
-}
-
-func (rr *ReviewRepository) FindAll() ([]Review, error) {
-	rows, err := rr.db.Query(`
-SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles 
-FROM review`)
-	if err != nil {
-		return nil, err
 	}
-
-	reviews := make([]Review, 0)
-	var titles string
-	for rows.Next() {
-		r := Review{}
-		if err := rows.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles); err != nil {
-			return nil, err
+	for _, r := range stored {
+		if _, ok := known[r.URL]; !ok || r.Source != source || !r.VanishedAt.IsZero() {
+			continue
 		}
-		if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
-			return []Review{}, err
+		if err := UpdateReview(ctx, reviewRepo, r, func(r *Review) {
+			r.VanishedAt = now
+		}); err != nil {
+			return ReviewMerge{}, err
 		}
-		reviews = append(reviews, r)
-	}
-	rows.Close()
-
-	return reviews, nil
-}
-
-func (rr *ReviewRepository) DeleteByMovieID(id string) error {
-	if _, err := rr.db.Exec(`DELETE FROM review WHERE movie_id=$1`, id); err != nil {
-		return err
+		r.VanishedAt = now
+		merge.Vanished = append(merge.Vanished, r)
 	}
 
-	return nil
+	return merge, nil
 }
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMergeReviews refreshes the reviews of one movie a few times, like the
+// worker does.
+func TestMergeReviews(t *testing.T) {
+	ctx := testContext(t)
+	reviews := NewMemory().Reviews()
+	vanished := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, r := range []Review{
+		{ID: "1", MovieID: "m", Source: "imdb", URL: "a", Review: "Good.", MovieRating: 8, Quality: 7},
+		{ID: "2", MovieID: "m", Source: "imdb", URL: "b", Review: "Bad.", MovieRating: 2, VanishedAt: vanished},
+		{ID: "3", MovieID: "m", Source: "other", URL: "c", Review: "Fine."},
+	} {
+		if err := reviews.Store(ctx, r); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	merge := func(fetched []Review, expAdded, expUpdated, expVanished int) map[string]Review {
+		t.Helper()
+		act, err := MergeReviews(ctx, reviews, "m", "imdb", fetched)
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		if len(act.Added) != expAdded || len(act.Updated) != expUpdated || len(act.Vanished) != expVanished {
+			t.Errorf("exp %d added, %d updated and %d vanished, got %v", expAdded, expUpdated, expVanished, act)
+		}
+		all, err := reviews.FindByMovieID(ctx, "m")
+		if err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		byURL := make(map[string]Review)
+		for _, r := range all {
+			byURL[r.URL] = r
+		}
+		return byURL
+	}
+
+	// a new review is added, the vanished one stays vanished
+	byURL := merge([]Review{
+		{URL: "a", Review: "Good.", MovieRating: 8},
+		{URL: "d", Review: "New.", MovieRating: 5, Quality: 3},
+	}, 1, 0, 0)
+	if len(byURL) != 4 {
+		t.Errorf("exp 4 reviews, got %d", len(byURL))
+	}
+	if d := byURL["d"]; d.MovieID != "m" || d.Source != "imdb" || d.Quality != 3 {
+		t.Errorf("exp d to be added to m, got %v", d)
+	}
+	if byURL["b"].VanishedAt.IsZero() {
+		t.Errorf("exp b to stay vanished")
+	}
+
+	// changed text is taken over, the quality is kept, b is back
+	byURL = merge([]Review{
+		{URL: "a", Review: "Very good.", MovieRating: 9, Quality: 1},
+		{URL: "b", Review: "Bad.", MovieRating: 2},
+		{URL: "d", Review: "New.", MovieRating: 5},
+	}, 0, 2, 0)
+	if a := byURL["a"]; a.Review != "Very good." || a.MovieRating != 9 || a.Quality != 7 {
+		t.Errorf("exp the new text with quality 7, got %v", a)
+	}
+	if !byURL["b"].VanishedAt.IsZero() {
+		t.Errorf("exp b to be back, got %v", byURL["b"].VanishedAt)
+	}
+
+	// everything from imdb vanishes, the other source is left alone
+	byURL = merge([]Review{}, 0, 0, 3)
+	for _, url := range []string{"a", "b", "d"} {
+		if byURL[url].VanishedAt.IsZero() {
+			t.Errorf("exp %s to have vanished", url)
+		}
+	}
+	if c := byURL["c"]; c.Review != "Fine." || !c.VanishedAt.IsZero() {
+		t.Errorf("exp c unchanged, got %v", c)
+	}
+}
+
+func TestMergeReviewsTwice(t *testing.T) {
+	for name, b := range map[string]Backend{
+		"memory": NewMemory(),
+		"sqlite": newTestSQLiteBackend(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(t)
+			if err := b.Movies().Store(ctx, Movie{ID: "m", Title: "Ran"}); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			fetched := []Review{
+				{ID: "1", URL: "a", Review: "Good."},
+				{ID: "2", URL: "b", Review: "Bad."},
+				{ID: "3", URL: "a", Review: "Good, again."},
+			}
+
+			merge, err := MergeReviews(ctx, b.Reviews(), "m", "imdb", fetched)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(merge.Added) != 2 {
+				t.Errorf("exp 2 added, got %v", merge.Added)
+			}
+			// the duplicate does not change the review on the next refresh
+			if merge, err = MergeReviews(ctx, b.Reviews(), "m", "imdb", fetched); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(merge.Updated) != 0 {
+				t.Errorf("exp no updates, got %v", merge.Updated)
+			}
+			all, err := b.Reviews().FindByMovieID(ctx, "m")
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			for _, r := range all {
+				if r.URL == "a" && r.Review != "Good." {
+					t.Errorf("exp the first text, got %q", r.Review)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	SnippetStart = "«"
+	SnippetStop  = "»"
+
+	snippetContext = 30
+	snippetLength  = 120
+
+	postgresHeadlineOptions = "StartSel=" + SnippetStart + ", StopSel=" + SnippetStop + ", MinWords=10, MaxWords=25"
+)
+
+// SearchHit is a movie that matches a search. The snippet is the part of the
+// text that matches best, with the matching words between SnippetStart and
+// SnippetStop.
+type SearchHit struct {
+	Movie   Movie
+	Rank    float64
+	Snippet string
+}
+
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// Weights for the titles, for the summary and comment, and for reviews. They
+// follow the weights Postgres gives to the A and B labels of a tsvector.
+const (
+	weightTitle  = 1.0
+	weightText   = 0.4
+	weightReview = 0.2
+)
+
+// searchTerms splits a search in lowercase words.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// rankMovie is the search for databases without full-text search. It
+// follows the full-text search of Postgres: all terms must occur in the
+// titles, summary and comment of the movie together, or all in one review.
+// Only the parts that match count for the rank and the snippet.
+func rankMovie(m Movie, reviews []string, terms []string) (SearchHit, bool) {
+	if len(terms) == 0 {
+		return SearchHit{}, false
+	}
+	docs := [][]searchField{{
+		{m.Title, weightTitle},
+		{m.EnglishTitle, weightTitle},
+		{m.Summary, weightText},
+		{m.Comment, weightText},
+	}}
+	for _, r := range reviews {
+		docs = append(docs, []searchField{{r, weightReview}})
+	}
+
+	hit := SearchHit{Movie: m}
+	matched := false
+	best := 0.0
+	for _, doc := range docs {
+		found := make(map[string]bool)
+		scores := make([]float64, len(doc))
+		for i, f := range doc {
+			lower := strings.ToLower(f.text)
+			for _, t := range terms {
+				if n := strings.Count(lower, t); n > 0 {
+					found[t] = true
+					scores[i] += f.weight * float64(n)
+				}
+			}
+		}
+		if len(found) < len(terms) {
+			continue
+		}
+		matched = true
+		for i, score := range scores {
+			hit.Rank += score
+			if score > best {
+				best = score
+				hit.Snippet = snippet(doc[i].text, terms)
+			}
+		}
+	}
+
+	return hit, matched
+}
+
+// snippet cuts the text around the first match and marks all matches.
+func snippet(text string, terms []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	type match struct{ start, end int }
+	matches := make([]match, 0)
+	for _, t := range terms {
+		term := []rune(t)
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if string(lower[i:i+len(term)]) == t {
+				matches = append(matches, match{i, i + len(term)})
+				i += len(term) - 1
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	from := max(0, matches[0].start-snippetContext)
+	to := min(len(runes), from+snippetLength)
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < pos || m.end > to {
+			continue
+		}
+		b.WriteString(string(runes[pos:m.start]))
+		b.WriteString(SnippetStart + string(runes[m.start:m.end]) + SnippetStop)
+		pos = m.end
+	}
+	b.WriteString(string(runes[pos:to]))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// sortHits puts the best hits first and keeps at most limit of them, if the
+// limit is positive.
+func sortHits(hits []SearchHit, limit int) []SearchHit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Movie.Title < hits[j].Movie.Title
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// withMovies fills in the hits with the complete movies and drops the hits
+// for movies that were not found.
+func withMovies(hits []SearchHit, movies []Movie) []SearchHit {
+	index := make(map[string]Movie, len(movies))
+	for _, m := range movies {
+		index[m.ID] = m
+	}
+
+	found := make([]SearchHit, 0, len(hits))
+	for _, h := range hits {
+		if m, ok := index[h.Movie.ID]; ok {
+			h.Movie = m
+			found = append(found, h)
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	for text, exp := range map[string][]string{
+		"":                          {},
+		"Seven Samurai":             {"seven", "samurai"},
+		"  Kurosawa's  1954, film!": {"kurosawa", "s", "1954", "film"},
+		"Amélie":                    {"amélie"},
+	} {
+		if act := searchTerms(text); strings.Join(act, "|") != strings.Join(exp, "|") {
+			t.Errorf("%q: exp %v, got %v", text, exp, act)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a ", 30) + "samurai" + strings.Repeat(" b", 70)
+
+	for _, tc := range []struct {
+		name  string
+		text  string
+		terms []string
+		exp   string
+	}{
+		{
+			name:  "no match",
+			text:  "Seven Samurai",
+			terms: []string{"ran"},
+			exp:   "",
+		},
+		{
+			name:  "short text",
+			text:  "Seven Samurai",
+			terms: []string{"samurai"},
+			exp:   "Seven «Samurai»",
+		},
+		{
+			name:  "more terms",
+			text:  "Seven  Samurai\nand seven more",
+			terms: []string{"seven", "samurai"},
+			exp:   "«Seven» «Samurai» and «seven» more",
+		},
+		{
+			name:  "inside a word",
+			text:  "Samurais",
+			terms: []string{"samurai"},
+			exp:   "«Samurai»s",
+		},
+		{
+			name:  "long text",
+			text:  long,
+			terms: []string{"samurai"},
+			exp:   "…a" + strings.Repeat(" a", 14) + " «samurai»" + strings.Repeat(" b", 41) + " …",
+		},
+		{
+			name:  "match past the end",
+			text:  "samurai" + strings.Repeat(" b", 60) + " seven",
+			terms: []string{"samurai", "seven"},
+			exp:   "«samurai»" + strings.Repeat(" b", 56) + " …",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if act := snippet(tc.text, tc.terms); act != tc.exp {
+				t.Errorf("exp %q, got %q", tc.exp, act)
+			}
+		})
+	}
+}
+
+func TestRankMovie(t *testing.T) {
+	m := Movie{
+		Title:        "Shichinin no samurai",
+		EnglishTitle: "Seven Samurai",
+		Summary:      "A village hires seven masterless samurai.",
+	}
+
+	for _, tc := range []struct {
+		name       string
+		reviews    []string
+		terms      []string
+		expMatch   bool
+		expRank    float64
+		expSnippet string
+	}{
+		{
+			name:  "no terms",
+			terms: []string{},
+		},
+		{
+			name:  "no match",
+			terms: []string{"ran"},
+		},
+		{
+			name:       "title",
+			terms:      []string{"samurai"},
+			expMatch:   true,
+			expRank:    2*weightTitle + weightText,
+			expSnippet: "Shichinin no «samurai»",
+		},
+		{
+			name:       "terms over the fields",
+			terms:      []string{"village", "shichinin"},
+			expMatch:   true,
+			expRank:    weightTitle + weightText,
+			expSnippet: "«Shichinin» no samurai",
+		},
+		{
+			name:     "terms over the movie and a review",
+			reviews:  []string{"A masterpiece."},
+			terms:    []string{"village", "masterpiece"},
+			expMatch: false,
+		},
+		{
+			name:       "all terms in a review",
+			reviews:    []string{"A masterpiece.", "A masterpiece about a village."},
+			terms:      []string{"village", "masterpiece"},
+			expMatch:   true,
+			expRank:    2 * weightReview,
+			expSnippet: "A «masterpiece» about a «village».",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			act, ok := rankMovie(m, tc.reviews, tc.terms)
+			if ok != tc.expMatch {
+				t.Fatalf("exp %v, got %v", tc.expMatch, ok)
+			}
+			if !ok {
+				return
+			}
+			if diff := act.Rank - tc.expRank; diff > 0.0001 || diff < -0.0001 {
+				t.Errorf("exp %v, got %v", tc.expRank, act.Rank)
+			}
+			if act.Snippet != tc.expSnippet {
+				t.Errorf("exp %q, got %q", tc.expSnippet, act.Snippet)
+			}
+		})
+	}
+}
+
+func TestSortHits(t *testing.T) {
+	hits := []SearchHit{
+		{Movie: Movie{ID: "a", Title: "B"}, Rank: 1},
+		{Movie: Movie{ID: "b", Title: "A"}, Rank: 1},
+		{Movie: Movie{ID: "c", Title: "C"}, Rank: 3},
+	}
+	ids := func(hits []SearchHit) string {
+		ids := make([]string, 0, len(hits))
+		for _, h := range hits {
+			ids = append(ids, h.Movie.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// the best rank first, then by title
+	if act := ids(sortHits(append([]SearchHit{}, hits...), 10)); act != "c,b,a" {
+		t.Errorf("exp c,b,a, got %s", act)
+	}
+	if act := ids(sortHits(append([]SearchHit{}, hits...), 2)); act != "c,b" {
+		t.Errorf("exp c,b, got %s", act)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	for name, b := range map[string]Backend{
+		"memory": NewMemory(),
+		"sqlite": newTestSQLiteBackend(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext(t)
+			for _, m := range []Movie{
+				{ID: "seven", Title: "Seven Samurai", Summary: "A village hires seven samurai.", Status: StatusWatched},
+				{ID: "ran", Title: "Ran", Summary: "An old lord divides his realm.", Status: StatusWatched},
+				{ID: "yojimbo", Title: "Yojimbo", Summary: "A samurai plays two gangs against each other.", Status: StatusWantToWatch},
+			} {
+				if err := b.Movies().Store(ctx, m); err != nil {
+					t.Fatalf("exp nil, got %v", err)
+				}
+			}
+			if err := b.Reviews().Store(ctx, Review{ID: "r", MovieID: "ran", Source: "imdb", URL: "r", Review: "Samurai and a storm."}); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+
+			hits, err := b.Movies().Search(ctx, "samurai", []Status{StatusWatched}, 10)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(hits) != 2 {
+				t.Fatalf("exp 2 hits, got %d", len(hits))
+			}
+			if hits[0].Movie.ID != "seven" {
+				t.Errorf("exp the title match first, got %s", hits[0].Movie.ID)
+			}
+			if hits[1].Movie.ID != "ran" || !strings.Contains(hits[1].Snippet, "Samurai") {
+				t.Errorf("exp ran with a snippet of its review, got %s and %q", hits[1].Movie.ID, hits[1].Snippet)
+			}
+
+			// a review in the trash is not searched
+			if err := b.Reviews().DeleteByMovieID(ctx, "ran"); err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			hits, err = b.Movies().Search(ctx, "storm", nil, 10)
+			if err != nil {
+				t.Fatalf("exp nil, got %v", err)
+			}
+			if len(hits) != 0 {
+				t.Errorf("exp no hits, got %v", hits)
+			}
+		})
+	}
+}
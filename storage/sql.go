@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// dialect holds what differs between the SQL databases. Queries are written
+// with ? for their arguments, rebind turns those into the placeholders of
+// the database.
+type dialect struct {
+	name string
+	// numbered tells whether placeholders are numbered, like $1.
+	numbered bool
+	failure  error
+	// classify sorts an error of the database in one of the kinds.
+	classify func(error) error
+	// tableExists counts the tables with the name of its argument.
+	tableExists string
+	like        string
+	// watchedOn is an expression for the watch date that sorts the same as
+	// the sort value of a movie.
+	watchedOn string
+	noLimit   string
+	// fullText tells whether the database has a full-text index to search
+	// in.
+	fullText bool
+}
+
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// list returns the placeholders for a list of n values.
+func list(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// SQL is a backend that keeps its data in Postgres or SQLite. The
+// repositories and the job queue run the same queries on both, only the
+// parts in the dialect differ.
+type SQL struct {
+	db         *sql.DB
+	actor      string
+	dialect    dialect
+	migrations []Migration
+}
+
+func (s *SQL) Movies() MovieRepository {
+	return NewSQLMovieRepository(s)
+}
+
+func (s *SQL) Reviews() ReviewRepository {
+	return NewSQLReviewRepository(s)
+}
+
+func (s *SQL) Viewings() ViewingRepository {
+	return NewSQLViewingRepository(s)
+}
+
+func (s *SQL) Lists() ListRepository {
+	return NewSQLListRepository(s)
+}
+
+func (s *SQL) Audit() AuditRepository {
+	return NewSQLAuditRepository(s)
+}
+
+func (s *SQL) Images() ImageRepository {
+	return NewSQLImageRepository(s)
+}
+
+func (s *SQL) Migrator() *Migrator {
+	return newMigrator(s.db, s.dialect, s.migrations)
+}
+
+// Type is TypePostgres or TypeSQLite, for the few queries of the job queue
+// that differ.
+func (s *SQL) Type() string {
+	return s.dialect.name
+}
+
+// Error sorts an error of the database in one of the kinds. It is exported
+// for the job queue, that shares the database.
+func (s *SQL) Error(err error) error {
+	return s.dialect.classify(err)
+}
+
+// InTx runs fn as a unit of work. Everything that is done with the context
+// that fn gets, in the repositories and in the job queue, is committed when
+// fn returns nil and rolled back otherwise.
+func (s *SQL) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, s.db, s.dialect.classify, fn)
+}
+
+func (s *SQL) begin(ctx context.Context) (*txn, error) {
+	return begin(ctx, s.db, s.dialect.rebind)
+}
+
+func (s *SQL) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return conn(ctx, s.db).ExecContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *SQL) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return conn(ctx, s.db).QueryRowContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *SQL) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return conn(ctx, s.db).QueryContext(ctx, s.dialect.rebind(query), args...)
+}
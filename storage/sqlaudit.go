@@ -0,0 +1,54 @@
+package storage
+
+import "context"
+
+const insertAudit = `INSERT INTO audit (revision, table_name, row_id, field, old_value, new_value, actor) 
+VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+type SQLAuditRepository struct {
+	db *SQL
+}
+
+func NewSQLAuditRepository(db *SQL) *SQLAuditRepository {
+	return &SQLAuditRepository{
+		db: db,
+	}
+}
+
+func (ar *SQLAuditRepository) FindByRow(ctx context.Context, table, rowID string) ([]Revision, error) {
+	rows, err := ar.db.QueryContext(ctx, `
+SELECT revision, table_name, row_id, actor, changed_at, field, old_value, new_value 
+FROM audit 
+WHERE table_name=? AND row_id=? 
+ORDER BY id DESC`, table, rowID)
+	if err != nil {
+		return nil, ar.db.Error(err)
+	}
+	defer rows.Close()
+
+	revisions, err := scanRevisions(rows)
+	if err != nil {
+		return nil, ar.db.Error(err)
+	}
+
+	return revisions, nil
+}
+
+func (ar *SQLAuditRepository) FindByActor(ctx context.Context, table, actor string) ([]Revision, error) {
+	rows, err := ar.db.QueryContext(ctx, `
+SELECT revision, table_name, row_id, actor, changed_at, field, old_value, new_value 
+FROM audit 
+WHERE table_name=? AND actor=? 
+ORDER BY id DESC`, table, actor)
+	if err != nil {
+		return nil, ar.db.Error(err)
+	}
+	defer rows.Close()
+
+	revisions, err := scanRevisions(rows)
+	if err != nil {
+		return nil, ar.db.Error(err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import "context"
+
+type SQLImageRepository struct {
+	db *SQL
+}
+
+func NewSQLImageRepository(db *SQL) *SQLImageRepository {
+	return &SQLImageRepository{
+		db: db,
+	}
+}
+
+func (ir *SQLImageRepository) Store(ctx context.Context, i Image) error {
+	if _, err := ir.db.ExecContext(ctx, `INSERT INTO image (url, hash, file, size, fetched_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (url) DO UPDATE
+SET
+  hash = excluded.hash,
+  file = excluded.file,
+  size = excluded.size,
+  fetched_at = excluded.fetched_at;`,
+		i.URL, i.Hash, i.File, i.Size, i.FetchedAt); err != nil {
+		return ir.db.Error(err)
+	}
+
+	return nil
+}
+
+func (ir *SQLImageRepository) FindByURL(ctx context.Context, url string) (Image, error) {
+	row := ir.db.QueryRowContext(ctx, `
+SELECT url, hash, file, size, fetched_at
+FROM image
+WHERE url=?`, url)
+	if row.Err() != nil {
+		return Image{}, ir.db.Error(row.Err())
+	}
+
+	i := Image{}
+	if err := row.Scan(&i.URL, &i.Hash, &i.File, &i.Size, &i.FetchedAt); err != nil {
+		return Image{}, ir.db.Error(err)
+	}
+
+	return i, nil
+}
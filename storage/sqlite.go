@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrSQLiteFailure = errors.New("sqlite failure")
+)
+
+var sqliteMigrations = []Migration{
+	{
+		Version: 1,
+		Up: `CREATE TABLE movie (
+	"id" TEXT UNIQUE NOT NULL,
+	"imdb_id" TEXT NOT NULL DEFAULT '',
+	"tmdb_id" INTEGER NOT NULL DEFAULT 0,
+	"title" TEXT NOT NULL DEFAULT '',
+	"english_title" TEXT NOT NULL DEFAULT '',
+	"year" INTEGER NOT NULL DEFAULT 0,
+	"directors" TEXT NOT NULL DEFAULT '',
+	"summary" TEXT NOT NULL DEFAULT '',
+	"watched_on" TEXT NOT NULL DEFAULT '',
+	"rating" INTEGER NOT NULL DEFAULT 0,
+	"comment" TEXT NOT NULL DEFAULT ''
+	);`,
+		Down: `DROP TABLE movie;`,
+	},
+	{
+		Version: 2,
+		Up: `CREATE TABLE review (
+	"id" TEXT UNIQUE NOT NULL,
+	"movie_id" TEXT NOT NULL,
+	"source" TEXT NOT NULL DEFAULT '',
+	"url" TEXT NOT NULL DEFAULT '',
+	"review" TEXT NOT NULL DEFAULT '',
+	"references" TEXT NOT NULL DEFAULT '',
+	"quality" INTEGER NOT NULL DEFAULT 0,
+	"mentions" TEXT NOT NULL DEFAULT '',
+	"movie_rating" INTEGER NOT NULL DEFAULT 0,
+	"mentioned_titles" TEXT NOT NULL DEFAULT '[]'
+	);`,
+		Down: `DROP TABLE review;`,
+	},
+	{
+		Version: 3,
+		Up: `CREATE TABLE job_queue (
+	"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"action_id" TEXT NOT NULL,
+	"action" TEXT NOT NULL DEFAULT '',
+	"status" TEXT NOT NULL DEFAULT '',
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+		Down: `DROP TABLE job_queue;`,
+	},
+	{
+		Version: 4,
+		Up: `CREATE TABLE person (
+	"tmdb_id" INTEGER PRIMARY KEY,
+	"name" TEXT NOT NULL DEFAULT ''
+	);
+CREATE TABLE movie_person (
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"person_id" INTEGER NOT NULL REFERENCES person ("tmdb_id"),
+	"role" TEXT NOT NULL,
+	"position" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("movie_id", "person_id", "role")
+	);
+CREATE INDEX movie_person_person_id ON movie_person ("person_id", "role");`,
+		Down: `DROP TABLE movie_person;
+DROP TABLE person;`,
+		Convert: backfillDirectors(sqliteDialect),
+	},
+	{
+		Version: 5,
+		Up: `ALTER TABLE movie_person ADD COLUMN "job" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie_person ADD COLUMN "character" TEXT NOT NULL DEFAULT '';`,
+		Down: `DELETE FROM movie_person WHERE role <> 'director';
+ALTER TABLE movie_person DROP COLUMN "character";
+ALTER TABLE movie_person DROP COLUMN "job";`,
+	},
+	{
+		Version: 6,
+		Up: `CREATE TABLE viewing (
+	"id" TEXT PRIMARY KEY,
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"watched_on" TEXT NOT NULL DEFAULT '',
+	"rating" INTEGER NOT NULL DEFAULT 0,
+	"notes" TEXT NOT NULL DEFAULT '',
+	"location" TEXT NOT NULL DEFAULT ''
+	);
+CREATE INDEX viewing_movie_id ON viewing ("movie_id");
+INSERT INTO viewing (id, movie_id, watched_on, rating)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))), id, watched_on, rating FROM movie WHERE watched_on <> '';`,
+		Down: `DROP TABLE viewing;`,
+	},
+	{
+		Version: 7,
+		Up: `ALTER TABLE movie RENAME COLUMN watched_on TO watched_on_text;
+ALTER TABLE movie ADD COLUMN watched_on DATE;
+ALTER TABLE viewing RENAME COLUMN watched_on TO watched_on_text;
+ALTER TABLE viewing ADD COLUMN watched_on DATE;
+CREATE TABLE unparsed_date (
+	"table_name" TEXT NOT NULL,
+	"row_id" TEXT NOT NULL,
+	"value" TEXT NOT NULL,
+	PRIMARY KEY ("table_name", "row_id")
+	);`,
+		Down: `ALTER TABLE movie ADD COLUMN watched_on_text TEXT NOT NULL DEFAULT '';
+UPDATE movie SET watched_on_text = COALESCE(watched_on, '');
+UPDATE movie SET watched_on_text = (SELECT value FROM unparsed_date u WHERE u.table_name = 'movie' AND u.row_id = movie.id)
+WHERE id IN (SELECT row_id FROM unparsed_date WHERE table_name = 'movie');
+ALTER TABLE movie DROP COLUMN watched_on;
+ALTER TABLE movie RENAME COLUMN watched_on_text TO watched_on;
+ALTER TABLE viewing ADD COLUMN watched_on_text TEXT NOT NULL DEFAULT '';
+UPDATE viewing SET watched_on_text = COALESCE(watched_on, '');
+UPDATE viewing SET watched_on_text = (SELECT value FROM unparsed_date u WHERE u.table_name = 'viewing' AND u.row_id = viewing.id)
+WHERE id IN (SELECT row_id FROM unparsed_date WHERE table_name = 'viewing');
+ALTER TABLE viewing DROP COLUMN watched_on;
+ALTER TABLE viewing RENAME COLUMN watched_on_text TO watched_on;
+DROP TABLE unparsed_date;`,
+		Convert: convertWatchedOn(
+			`UPDATE %s SET watched_on = ? WHERE id = ?`,
+			`INSERT INTO unparsed_date (table_name, row_id, value) VALUES (?, ?, ?)`,
+		),
+	},
+	{
+		Version: 8,
+		Up: `ALTER TABLE movie ADD COLUMN "status" TEXT NOT NULL DEFAULT 'watched';
+ALTER TABLE movie ADD COLUMN "priority" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "recommended_by" TEXT NOT NULL DEFAULT '';
+CREATE INDEX movie_status ON movie ("status", "priority");`,
+		Down: `DROP INDEX movie_status;
+ALTER TABLE movie DROP COLUMN "recommended_by";
+ALTER TABLE movie DROP COLUMN "priority";
+ALTER TABLE movie DROP COLUMN "status";`,
+	},
+	{
+		Version: 9,
+		Up: `CREATE TABLE movie_tag (
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"tag" TEXT NOT NULL,
+	PRIMARY KEY ("movie_id", "tag")
+	);
+CREATE INDEX movie_tag_tag ON movie_tag ("tag");
+CREATE TABLE list (
+	"id" TEXT PRIMARY KEY,
+	"name" TEXT UNIQUE NOT NULL,
+	"description" TEXT NOT NULL DEFAULT ''
+	);
+CREATE TABLE list_movie (
+	"list_id" TEXT NOT NULL REFERENCES list ("id") ON DELETE CASCADE,
+	"movie_id" TEXT NOT NULL REFERENCES movie ("id") ON DELETE CASCADE,
+	"position" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("list_id", "movie_id")
+	);`,
+		Down: `DROP TABLE list_movie;
+DROP TABLE list;
+DROP TABLE movie_tag;`,
+	},
+	{
+		Version: 10,
+		Up: `CREATE TABLE audit (
+	"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"revision" TEXT NOT NULL,
+	"table_name" TEXT NOT NULL,
+	"row_id" TEXT NOT NULL,
+	"field" TEXT NOT NULL,
+	"old_value" TEXT NOT NULL DEFAULT '',
+	"new_value" TEXT NOT NULL DEFAULT '',
+	"actor" TEXT NOT NULL DEFAULT '',
+	"changed_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+CREATE INDEX audit_row ON audit ("table_name", "row_id");`,
+		Down: `DROP TABLE audit;`,
+	},
+	{
+		Version: 11,
+		Up: `ALTER TABLE movie ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE review ADD COLUMN "deleted_at" TIMESTAMP;
+ALTER TABLE viewing ADD COLUMN "deleted_at" TIMESTAMP;
+CREATE INDEX movie_deleted_at ON movie ("deleted_at");`,
+		Down: `DROP INDEX movie_deleted_at;
+ALTER TABLE viewing DROP COLUMN "deleted_at";
+ALTER TABLE review DROP COLUMN "deleted_at";
+ALTER TABLE movie DROP COLUMN "deleted_at";`,
+	},
+	{
+		Version: 12,
+		Up: `ALTER TABLE movie ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE review ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;`,
+		Down: `ALTER TABLE review DROP COLUMN "version";
+ALTER TABLE movie DROP COLUMN "version";`,
+	},
+	{
+		Version: 13,
+		// reviews used to be stored again on every refresh, keep the one
+		// that was rated
+		Up: `DELETE FROM review
+WHERE EXISTS (
+	SELECT 1 FROM review other
+	WHERE other.source = review.source AND other.url = review.url
+	  AND (other.quality > review.quality OR (other.quality = review.quality AND other.id < review.id))
+	);
+ALTER TABLE review ADD COLUMN "vanished_at" TIMESTAMP;
+CREATE UNIQUE INDEX review_source_url ON review ("source", "url");`,
+		Down: `DROP INDEX review_source_url;
+ALTER TABLE review DROP COLUMN "vanished_at";`,
+	},
+	{
+		Version: 14,
+		Up: `ALTER TABLE movie ADD COLUMN "runtime" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "genres" TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE movie ADD COLUMN "countries" TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE movie ADD COLUMN "original_language" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "tagline" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "vote_average" REAL NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "vote_count" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie ADD COLUMN "poster_path" TEXT NOT NULL DEFAULT '';
+ALTER TABLE movie ADD COLUMN "backdrop_path" TEXT NOT NULL DEFAULT '';`,
+		Down: `ALTER TABLE movie DROP COLUMN "backdrop_path";
+ALTER TABLE movie DROP COLUMN "poster_path";
+ALTER TABLE movie DROP COLUMN "vote_count";
+ALTER TABLE movie DROP COLUMN "vote_average";
+ALTER TABLE movie DROP COLUMN "tagline";
+ALTER TABLE movie DROP COLUMN "original_language";
+ALTER TABLE movie DROP COLUMN "countries";
+ALTER TABLE movie DROP COLUMN "genres";
+ALTER TABLE movie DROP COLUMN "runtime";`,
+	},
+	{
+		Version: 15,
+		Up: `CREATE TABLE image (
+	"url" TEXT PRIMARY KEY,
+	"hash" TEXT NOT NULL,
+	"file" TEXT NOT NULL,
+	"size" INTEGER NOT NULL DEFAULT 0,
+	"fetched_at" TIMESTAMP NOT NULL
+	);`,
+		Down: `DROP TABLE image;`,
+	},
+	{
+		Version: 16,
+		Up: `ALTER TABLE job_queue ADD COLUMN "worker" TEXT NOT NULL DEFAULT '';
+CREATE INDEX job_queue_status ON job_queue ("status", "id");`,
+		Down: `DROP INDEX job_queue_status;
+ALTER TABLE job_queue DROP COLUMN "worker";`,
+	},
+	{
+		Version: 17,
+		Up:      `ALTER TABLE job_queue ADD COLUMN "lease_until" TIMESTAMP;`,
+		Down:    `ALTER TABLE job_queue DROP COLUMN "lease_until";`,
+	},
+	{
+		Version: 18,
+		Up: `ALTER TABLE job_queue ADD COLUMN "attempts" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE job_queue ADD COLUMN "not_before" TIMESTAMP;
+ALTER TABLE job_queue ADD COLUMN "last_error" TEXT NOT NULL DEFAULT '';`,
+		Down: `ALTER TABLE job_queue DROP COLUMN "last_error";
+ALTER TABLE job_queue DROP COLUMN "not_before";
+ALTER TABLE job_queue DROP COLUMN "attempts";`,
+	},
+	{
+		Version: 19,
+		Up: `ALTER TABLE job_queue RENAME COLUMN "not_before" TO "run_at";
+CREATE TABLE job_schedule (
+	"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"name" TEXT NOT NULL UNIQUE,
+	"cron" TEXT NOT NULL,
+	"action" TEXT NOT NULL,
+	"action_id" TEXT NOT NULL DEFAULT '',
+	"next_run" TIMESTAMP NOT NULL,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+		Down: `DROP TABLE job_schedule;
+ALTER TABLE job_queue RENAME COLUMN "run_at" TO "not_before";`,
+	},
+	{
+		Version: 20,
+		Up: `ALTER TABLE job_queue ADD COLUMN "priority" INTEGER NOT NULL DEFAULT 0;
+DROP INDEX job_queue_status;
+CREATE INDEX job_queue_next ON job_queue ("status", "priority" DESC, "id");`,
+		Down: `DROP INDEX job_queue_next;
+CREATE INDEX job_queue_status ON job_queue ("status", "id");
+ALTER TABLE job_queue DROP COLUMN "priority";`,
+	},
+	{
+		Version: 21,
+		Up:      `ALTER TABLE job_schedule ADD COLUMN "timezone" TEXT NOT NULL DEFAULT 'UTC';`,
+		Down:    `ALTER TABLE job_schedule DROP COLUMN "timezone";`,
+	},
+	{
+		Version: 22,
+		// the directors are in movie_person since version 4
+		Up: `ALTER TABLE movie DROP COLUMN "directors";`,
+		Down: `ALTER TABLE movie ADD COLUMN "directors" TEXT NOT NULL DEFAULT '';
+UPDATE movie SET "directors" = COALESCE((
+	SELECT group_concat(name, ',') FROM (
+		SELECT p.name FROM movie_person mp JOIN person p ON p.tmdb_id = mp.person_id
+		WHERE mp.movie_id = movie.id AND mp.role = 'director'
+		ORDER BY mp.position)), '');`,
+	},
+}
+
+var sqliteDialect = dialect{
+	name:        TypeSQLite,
+	failure:     ErrSQLiteFailure,
+	classify:    sqliteError,
+	tableExists: `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+	like:        "LIKE",
+	watchedOn:   "COALESCE(watched_on, '')",
+	noLimit:     "-1",
+}
+
+func NewSQLite(path string) (*SQL, error) {
+	sl, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sl.Migrator().Up(); err != nil {
+		return &SQL{}, err
+	}
+
+	return sl, nil
+}
+
+func openSQLite(path string) (*SQL, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_foreign_keys=on", path))
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows only one writer at a time
+	db.SetMaxOpenConns(1)
+
+	return &SQL{
+		db:         db,
+		actor:      defaultActor(),
+		dialect:    sqliteDialect,
+		migrations: sqliteMigrations,
+	}, nil
+}
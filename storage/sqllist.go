@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type SQLListRepository struct {
+	db *SQL
+}
+
+func NewSQLListRepository(db *SQL) *SQLListRepository {
+	return &SQLListRepository{
+		db: db,
+	}
+}
+
+func (lr *SQLListRepository) Store(ctx context.Context, l List) error {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+
+	tx, err := lr.db.begin(ctx)
+	if err != nil {
+		return lr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO list (id, name, description)
+VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE
+SET
+  name = excluded.name,
+  description = excluded.description;`,
+		l.ID, l.Name, l.Description); err != nil {
+		return lr.db.Error(err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM list_movie WHERE list_id=?`, l.ID); err != nil {
+		return lr.db.Error(err)
+	}
+	for i, movieID := range l.MovieIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO list_movie (list_id, movie_id, position)
+VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;`, l.ID, movieID, i); err != nil {
+			return lr.db.Error(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return lr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (lr *SQLListRepository) Delete(ctx context.Context, id string) error {
+	if _, err := lr.db.ExecContext(ctx, `DELETE FROM list WHERE id=?`, id); err != nil {
+		return lr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (lr *SQLListRepository) FindOne(ctx context.Context, id string) (List, error) {
+	row := lr.db.QueryRowContext(ctx, `
+SELECT id, name, description
+FROM list
+WHERE id=?`, id)
+	if row.Err() != nil {
+		return List{}, lr.db.Error(row.Err())
+	}
+
+	l := List{}
+	if err := row.Scan(&l.ID, &l.Name, &l.Description); err != nil {
+		return List{}, lr.db.Error(err)
+	}
+
+	lists := []List{l}
+	if err := lr.findMovies(ctx, lists); err != nil {
+		return List{}, err
+	}
+
+	return lists[0], nil
+}
+
+func (lr *SQLListRepository) FindAll(ctx context.Context) ([]List, error) {
+	rows, err := lr.db.QueryContext(ctx, `
+SELECT id, name, description
+FROM list
+ORDER BY name`)
+	if err != nil {
+		return nil, lr.db.Error(err)
+	}
+	defer rows.Close()
+
+	lists := make([]List, 0)
+	for rows.Next() {
+		l := List{}
+		if err := rows.Scan(&l.ID, &l.Name, &l.Description); err != nil {
+			return nil, lr.db.Error(err)
+		}
+		lists = append(lists, l)
+	}
+	rows.Close()
+
+	if err := lr.findMovies(ctx, lists); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// findMovies fills in the movies on the lists, in order.
+func (lr *SQLListRepository) findMovies(ctx context.Context, lists []List) error {
+	rows, err := lr.db.QueryContext(ctx, `
+SELECT list_id, movie_id
+FROM list_movie
+ORDER BY list_id, position`)
+	if err != nil {
+		return lr.db.Error(err)
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(lists))
+	for i := range lists {
+		index[lists[i].ID] = i
+		lists[i].MovieIDs = make([]string, 0)
+	}
+	for rows.Next() {
+		var listID, movieID string
+		if err := rows.Scan(&listID, &movieID); err != nil {
+			return lr.db.Error(err)
+		}
+		if i, ok := index[listID]; ok {
+			lists[i].MovieIDs = append(lists[i].MovieIDs, movieID)
+		}
+	}
+
+	return nil
+}
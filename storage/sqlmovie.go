@@ -0,0 +1,681 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SQLMovieRepository struct {
+	db *SQL
+}
+
+func NewSQLMovieRepository(db *SQL) *SQLMovieRepository {
+	return &SQLMovieRepository{
+		db: db,
+	}
+}
+
+func (mr *SQLMovieRepository) Store(ctx context.Context, m Movie) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	m = m.normalize()
+	stored, err := mr.stored(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+	var version int
+	if stored != nil {
+		version = stored.Version
+		m.DeletedAt = stored.DeletedAt
+	}
+	if err := checkVersion(AuditTableMovie, m.ID, version, m.Version); err != nil {
+		return err
+	}
+	rev, err := mr.revision(ctx, stored, m)
+	if err != nil {
+		return err
+	}
+	genres, err := json.Marshal(m.Genres)
+	if err != nil {
+		return err
+	}
+	countries, err := json.Marshal(m.Countries)
+	if err != nil {
+		return err
+	}
+
+	tx, err := mr.db.begin(ctx)
+	if err != nil {
+		return mr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO movie (id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+ON CONFLICT (id) DO UPDATE
+SET
+  tmdb_id = excluded.tmdb_id,
+  imdb_id = excluded.imdb_id,
+  title = excluded.title,
+  english_title = excluded.english_title,
+  year = excluded.year,
+  summary = excluded.summary,
+  watched_on = excluded.watched_on,
+  rating = excluded.rating,
+  comment = excluded.comment,
+  status = excluded.status,
+  priority = excluded.priority,
+  recommended_by = excluded.recommended_by,
+  runtime = excluded.runtime,
+  genres = excluded.genres,
+  countries = excluded.countries,
+  original_language = excluded.original_language,
+  tagline = excluded.tagline,
+  vote_average = excluded.vote_average,
+  vote_count = excluded.vote_count,
+  poster_path = excluded.poster_path,
+  backdrop_path = excluded.backdrop_path,
+  version = movie.version + 1
+WHERE movie.version = ?;`,
+		m.ID, m.TMDBID, m.IMDBID, m.Title, m.EnglishTitle, m.Year, m.Summary, m.WatchedOn, m.Rating, m.Comment, m.Status, m.Priority, m.RecommendedBy,
+		m.Runtime, string(genres), string(countries), m.OriginalLanguage, m.Tagline, m.VoteAverage, m.VoteCount, m.PosterPath, m.BackdropPath, m.Version)
+	if err != nil {
+		return mr.db.Error(err)
+	}
+	if err := checkUpdated(AuditTableMovie, m.ID, res); err != nil {
+		return mr.db.Error(err)
+	}
+	if err := mr.storeCredits(ctx, tx, m.ID, m.Credits()); err != nil {
+		return err
+	}
+	if err := mr.storeTags(ctx, tx, m.ID, m.Tags); err != nil {
+		return err
+	}
+	if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+		return mr.db.Error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return mr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (mr *SQLMovieRepository) Delete(ctx context.Context, id string) error {
+	stored, err := mr.stored(ctx, id)
+	switch {
+	case err != nil:
+		return err
+	case stored == nil:
+		return fmt.Errorf("%w: movie %s", ErrNotFound, id)
+	case !stored.DeletedAt.IsZero():
+		return nil
+	}
+	deleted := *stored
+	deleted.DeletedAt = time.Now().UTC().Truncate(time.Second)
+	rev, err := mr.revision(ctx, stored, deleted)
+	if err != nil {
+		return err
+	}
+
+	tx, err := mr.db.begin(ctx)
+	if err != nil {
+		return mr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"movie", "review", "viewing"} {
+		column, version := "movie_id", ""
+		if table == "movie" {
+			column, version = "id", ", version=version+1"
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET deleted_at=?%s WHERE %s=? AND deleted_at IS NULL`, table, version, column), deleted.DeletedAt, id); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+	if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+		return mr.db.Error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return mr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (mr *SQLMovieRepository) Restore(ctx context.Context, id string) error {
+	stored, err := mr.stored(ctx, id)
+	switch {
+	case err != nil:
+		return err
+	case stored == nil:
+		return fmt.Errorf("%w: movie %s", ErrNotFound, id)
+	case stored.DeletedAt.IsZero():
+		return nil
+	}
+	restored := *stored
+	restored.DeletedAt = time.Time{}
+	rev, err := mr.revision(ctx, stored, restored)
+	if err != nil {
+		return err
+	}
+
+	tx, err := mr.db.begin(ctx)
+	if err != nil {
+		return mr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	// reviews that were deleted before the movie come back as well
+	for _, table := range []string{"review", "viewing"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET deleted_at=NULL WHERE movie_id=?`, table), id); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE movie SET deleted_at=NULL, version=version+1 WHERE id=?`, id); err != nil {
+		return mr.db.Error(err)
+	}
+	if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+		return mr.db.Error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return mr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (mr *SQLMovieRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	// deleted_at is stored in UTC
+	before = before.UTC()
+	trashed, err := mr.FindDeleted(ctx)
+	if err != nil {
+		return 0, err
+	}
+	revisions := make([]Revision, 0)
+	for _, m := range trashed {
+		if !m.DeletedAt.Before(before) {
+			continue
+		}
+		rev, err := newRevision(AuditTableMovie, m.ID, actorOf(ctx, mr.db.actor), m, nil)
+		if err != nil {
+			return 0, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	tx, err := mr.db.begin(ctx)
+	if err != nil {
+		return 0, mr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	// reviews have no foreign key to the movie, so they are not removed
+	// with it
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review
+WHERE deleted_at < ?
+  OR movie_id IN (SELECT id FROM movie WHERE deleted_at < ?)`, before, before); err != nil {
+		return 0, mr.db.Error(err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM viewing WHERE deleted_at < ?`, before); err != nil {
+		return 0, mr.db.Error(err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM movie WHERE deleted_at < ?`, before)
+	if err != nil {
+		return 0, mr.db.Error(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, mr.db.Error(err)
+	}
+	for _, rev := range revisions {
+		if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+			return 0, mr.db.Error(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, mr.db.Error(err)
+	}
+
+	return int(count), nil
+}
+
+func (mr *SQLMovieRepository) FindOne(ctx context.Context, id string) (Movie, error) {
+	row := mr.db.QueryRowContext(ctx, `
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE id=?`, id)
+	if row.Err() != nil {
+		return Movie{}, mr.db.Error(row.Err())
+	}
+
+	m, err := mr.scan(row)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	movies := []Movie{m}
+	if err := mr.findPeople(ctx, movies); err != nil {
+		return Movie{}, err
+	}
+	if err := mr.findTags(ctx, movies); err != nil {
+		return Movie{}, err
+	}
+
+	return movies[0], nil
+}
+
+func (mr *SQLMovieRepository) FindAll(ctx context.Context) ([]Movie, error) {
+	return mr.query(ctx, `
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE deleted_at IS NULL`)
+}
+
+func (mr *SQLMovieRepository) FindByPerson(ctx context.Context, personID int64, role Role) ([]Movie, error) {
+	return mr.query(ctx, `
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE id IN (SELECT movie_id FROM movie_person WHERE person_id=? AND role=?)
+  AND deleted_at IS NULL`, personID, role)
+}
+
+func (mr *SQLMovieRepository) FindByStatus(ctx context.Context, status Status) ([]Movie, error) {
+	return mr.query(ctx, `
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE status=? AND deleted_at IS NULL
+ORDER BY priority DESC, title`, status)
+}
+
+func (mr *SQLMovieRepository) Query(ctx context.Context, q MovieQuery) (MoviePage, error) {
+	pageQuery, args, countQuery, countArgs, err := mr.db.dialect.movieSQL(q, "id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version, runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path")
+	if err != nil {
+		return MoviePage{}, err
+	}
+
+	var total int
+	if err := mr.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return MoviePage{}, mr.db.Error(err)
+	}
+	movies, err := mr.query(ctx, pageQuery, args...)
+	if err != nil {
+		return MoviePage{}, err
+	}
+
+	return newMoviePage(movies, total, q)
+}
+
+func (mr *SQLMovieRepository) Search(ctx context.Context, text string, statuses []Status, limit int) ([]SearchHit, error) {
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+	status, statusArgs := "TRUE", []any{}
+	if len(statuses) > 0 {
+		status = fmt.Sprintf("m.status IN (%s)", list(len(statuses)))
+		for _, st := range statuses {
+			statusArgs = append(statusArgs, st)
+		}
+	}
+	if mr.db.dialect.fullText {
+		return mr.fullTextSearch(ctx, text, status, statusArgs, limit)
+	}
+
+	return mr.likeSearch(ctx, terms, status, statusArgs, limit)
+}
+
+// fullTextSearch matches the movie and each review on its own, like the
+// separate tsvectors they have. status filters the movies m.
+func (mr *SQLMovieRepository) fullTextSearch(ctx context.Context, text, status string, statusArgs []any, limit int) ([]SearchHit, error) {
+	var maxHits any
+	if limit > 0 {
+		maxHits = limit
+	}
+	args := []any{text, postgresHeadlineOptions}
+	args = append(args, statusArgs...)
+	args = append(args, weightReview, postgresHeadlineOptions)
+	args = append(args, statusArgs...)
+	args = append(args, maxHits)
+
+	rows, err := mr.db.QueryContext(ctx, fmt.Sprintf(`
+WITH q AS (SELECT websearch_to_tsquery('english', ?) AS query),
+hits AS (
+	SELECT m.id AS movie_id, ts_rank(m.search, q.query) AS rank,
+		ts_headline('english', concat_ws(' ', m.title, m.english_title, m.summary, m.comment), q.query, ?) AS snippet
+	FROM movie m, q
+	WHERE m.search @@ q.query AND m.deleted_at IS NULL AND %[1]s
+	UNION ALL
+	SELECT r.movie_id, ts_rank(r.search, q.query) * ?, ts_headline('english', r.review, q.query, ?)
+	FROM review r JOIN movie m ON m.id = r.movie_id, q
+	WHERE r.search @@ q.query AND r.deleted_at IS NULL AND m.deleted_at IS NULL AND %[1]s
+)
+SELECT movie_id, rank, snippet
+FROM (SELECT movie_id, SUM(rank) OVER (PARTITION BY movie_id) AS rank, snippet,
+		row_number() OVER (PARTITION BY movie_id ORDER BY rank DESC) AS n
+	FROM hits) best
+WHERE n = 1
+ORDER BY rank DESC
+LIMIT ?`, status), args...)
+	if err != nil {
+		return nil, mr.db.Error(err)
+	}
+	defer rows.Close()
+
+	hits := make([]SearchHit, 0)
+	for rows.Next() {
+		var h SearchHit
+		if err := rows.Scan(&h.Movie.ID, &h.Rank, &h.Snippet); err != nil {
+			return nil, mr.db.Error(err)
+		}
+		hits = append(hits, h)
+	}
+	rows.Close()
+	if len(hits) == 0 {
+		return hits, nil
+	}
+
+	ids := make([]any, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.Movie.ID)
+	}
+	movies, err := mr.query(ctx, fmt.Sprintf(`
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE id IN (%s)`, list(len(ids))), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return withMovies(hits, movies), nil
+}
+
+// likeSearch is for databases without a full-text index. It narrows the
+// movies down with LIKE and ranks the rest the same way as the memory
+// backend does.
+func (mr *SQLMovieRepository) likeSearch(ctx context.Context, terms []string, status string, statusArgs []any, limit int) ([]SearchHit, error) {
+	where := []string{status}
+	args := append(make([]any, 0, len(statusArgs)+5*len(terms)), statusArgs...)
+	for _, t := range terms {
+		where = append(where, `(title LIKE ? OR english_title LIKE ? OR summary LIKE ? OR comment LIKE ?
+  OR id IN (SELECT movie_id FROM review WHERE review LIKE ? AND deleted_at IS NULL))`)
+		like := "%" + t + "%"
+		args = append(args, like, like, like, like, like)
+	}
+	movies, err := mr.query(ctx, fmt.Sprintf(`
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie m
+WHERE deleted_at IS NULL
+  AND %s`, strings.Join(where, "\n  AND ")), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0)
+	for _, m := range movies {
+		reviews, err := mr.reviewTexts(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		if h, ok := rankMovie(m, reviews, terms); ok {
+			hits = append(hits, h)
+		}
+	}
+
+	return sortHits(hits, limit), nil
+}
+
+func (mr *SQLMovieRepository) FindDeleted(ctx context.Context) ([]Movie, error) {
+	return mr.query(ctx, `
+SELECT id, tmdb_id, imdb_id, title, english_title, year, summary, watched_on, rating, comment, status, priority, recommended_by, deleted_at, version,
+  runtime, genres, countries, original_language, tagline, vote_average, vote_count, poster_path, backdrop_path
+FROM movie
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, title`)
+}
+
+func (mr *SQLMovieRepository) query(ctx context.Context, query string, args ...any) ([]Movie, error) {
+	rows, err := mr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mr.db.Error(err)
+	}
+
+	movies := make([]Movie, 0)
+	defer rows.Close()
+	for rows.Next() {
+		m, err := mr.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, m)
+	}
+	rows.Close()
+
+	if err := mr.findPeople(ctx, movies); err != nil {
+		return nil, err
+	}
+	if err := mr.findTags(ctx, movies); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+func (mr *SQLMovieRepository) reviewTexts(ctx context.Context, movieID string) ([]string, error) {
+	rows, err := mr.db.QueryContext(ctx, `SELECT review FROM review WHERE movie_id=? AND deleted_at IS NULL`, movieID)
+	if err != nil {
+		return nil, mr.db.Error(err)
+	}
+	defer rows.Close()
+
+	texts := make([]string, 0)
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, mr.db.Error(err)
+		}
+		texts = append(texts, text)
+	}
+
+	return texts, nil
+}
+
+func (mr *SQLMovieRepository) scan(row scanner) (Movie, error) {
+	m := Movie{}
+	var deletedAt sql.NullTime
+	var genres, countries string
+	if err := row.Scan(&m.ID, &m.TMDBID, &m.IMDBID, &m.Title, &m.EnglishTitle, &m.Year, &m.Summary, &m.WatchedOn, &m.Rating, &m.Comment, &m.Status, &m.Priority, &m.RecommendedBy, &deletedAt, &m.Version,
+		&m.Runtime, &genres, &countries, &m.OriginalLanguage, &m.Tagline, &m.VoteAverage, &m.VoteCount, &m.PosterPath, &m.BackdropPath); err != nil {
+		return Movie{}, mr.db.Error(err)
+	}
+	m.DeletedAt = deletedAt.Time
+	if err := json.Unmarshal([]byte(genres), &m.Genres); err != nil {
+		return Movie{}, err
+	}
+	if err := json.Unmarshal([]byte(countries), &m.Countries); err != nil {
+		return Movie{}, err
+	}
+
+	return m, nil
+}
+
+// stored returns the movie as it is in the database, or nil if it is not
+// there. Within InTx it reads in the transaction of the unit of work. The
+// methods that write call it before they begin their own transaction, the
+// version in the WHERE of their update catches a change in between.
+func (mr *SQLMovieRepository) stored(ctx context.Context, id string) (*Movie, error) {
+	m, err := mr.FindOne(ctx, id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// revision compares the stored version of the movie with the new one.
+func (mr *SQLMovieRepository) revision(ctx context.Context, stored *Movie, m Movie) (Revision, error) {
+	if stored == nil {
+		return newRevision(AuditTableMovie, m.ID, actorOf(ctx, mr.db.actor), nil, m)
+	}
+
+	return newRevision(AuditTableMovie, m.ID, actorOf(ctx, mr.db.actor), *stored, m)
+}
+
+func (mr *SQLMovieRepository) storeCredits(ctx context.Context, tx *txn, movieID string, credits []Credit) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_person WHERE movie_id=?`, movieID); err != nil {
+		return mr.db.Error(err)
+	}
+
+	for i, c := range credits {
+		if c.Person.TMDBID == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO person (tmdb_id, name)
+VALUES (?, ?)
+ON CONFLICT (tmdb_id) DO UPDATE SET name = excluded.name;`, c.Person.TMDBID, c.Person.Name); err != nil {
+			return mr.db.Error(err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO movie_person (movie_id, person_id, role, job, "character", position)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;`, movieID, c.Person.TMDBID, c.Role, c.Job, c.Character, i); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+
+	return nil
+}
+
+func (mr *SQLMovieRepository) storeTags(ctx context.Context, tx *txn, movieID string, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM movie_tag WHERE movie_id=?`, movieID); err != nil {
+		return mr.db.Error(err)
+	}
+	for _, t := range normalizeTags(tags) {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO movie_tag (movie_id, tag) VALUES (?, ?)`, movieID, t); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// findTags fills in the tags of the movies.
+func (mr *SQLMovieRepository) findTags(ctx context.Context, movies []Movie) error {
+	index := make(map[string]int, len(movies))
+	for i := range movies {
+		index[movies[i].ID] = i
+		movies[i].Tags = make([]string, 0)
+	}
+
+	for _, ids := range idChunks(movies) {
+		rows, err := mr.db.QueryContext(ctx, fmt.Sprintf(`
+SELECT movie_id, tag
+FROM movie_tag
+WHERE movie_id IN (%s)
+ORDER BY movie_id, tag`, list(len(ids))), ids...)
+		if err != nil {
+			return mr.db.Error(err)
+		}
+		for rows.Next() {
+			var movieID, tag string
+			if err := rows.Scan(&movieID, &tag); err != nil {
+				rows.Close()
+				return mr.db.Error(err)
+			}
+			i := index[movieID]
+			movies[i].Tags = append(movies[i].Tags, tag)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// findPeople fills in the people that worked on the movies.
+func (mr *SQLMovieRepository) findPeople(ctx context.Context, movies []Movie) error {
+	index := make(map[string]int, len(movies))
+	for i := range movies {
+		index[movies[i].ID] = i
+		movies[i].Directors = make([]Person, 0)
+		movies[i].Crew = make([]Credit, 0)
+		movies[i].Cast = make([]Credit, 0)
+	}
+
+	for _, ids := range idChunks(movies) {
+		rows, err := mr.db.QueryContext(ctx, fmt.Sprintf(`
+SELECT mp.movie_id, mp.role, mp.job, mp."character", p.tmdb_id, p.name
+FROM movie_person mp
+JOIN person p ON p.tmdb_id = mp.person_id
+WHERE mp.movie_id IN (%s)
+ORDER BY mp.movie_id, mp.position`, list(len(ids))), ids...)
+		if err != nil {
+			return mr.db.Error(err)
+		}
+		for rows.Next() {
+			var movieID string
+			var c Credit
+			if err := rows.Scan(&movieID, &c.Role, &c.Job, &c.Character, &c.Person.TMDBID, &c.Person.Name); err != nil {
+				rows.Close()
+				return mr.db.Error(err)
+			}
+			i := index[movieID]
+			switch c.Role {
+			case RoleDirector:
+				movies[i].Directors = append(movies[i].Directors, c.Person)
+			case RoleCast:
+				movies[i].Cast = append(movies[i].Cast, c)
+			default:
+				movies[i].Crew = append(movies[i].Crew, c)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return mr.db.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// idChunk is the most movie ids that go in one IN list. Both databases limit
+// the number of arguments of a query.
+const idChunk = 500
+
+// idChunks splits the ids of the movies in lists of at most idChunk.
+func idChunks(movies []Movie) [][]any {
+	chunks := make([][]any, 0, len(movies)/idChunk+1)
+	for start := 0; start < len(movies); start += idChunk {
+		end := min(start+idChunk, len(movies))
+		ids := make([]any, 0, end-start)
+		for _, m := range movies[start:end] {
+			ids = append(ids, m.ID)
+		}
+		chunks = append(chunks, ids)
+	}
+
+	return chunks
+}
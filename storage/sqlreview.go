@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type SQLReviewRepository struct {
+	db *SQL
+}
+
+func NewSQLReviewRepository(db *SQL) *SQLReviewRepository {
+	return &SQLReviewRepository{
+		db: db,
+	}
+}
+
+func (rr *SQLReviewRepository) Store(ctx context.Context, r Review) error {
+	titles, err := json.Marshal(r.Mentions)
+	if err != nil {
+		return err
+	}
+	var old any
+	var version int
+	stored, err := rr.FindOne(ctx, r.ID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		old, version = stored, stored.Version
+	}
+	if err := checkVersion(AuditTableReview, r.ID, version, r.Version); err != nil {
+		return err
+	}
+	rev, err := newRevision(AuditTableReview, r.ID, actorOf(ctx, rr.db.actor), old, r)
+	if err != nil {
+		return err
+	}
+
+	tx, err := rr.db.begin(ctx)
+	if err != nil {
+		return rr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO review (id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, vanished_at, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+ON CONFLICT (id) DO UPDATE SET movie_id = excluded.movie_id, source = excluded.source, url = excluded.url,
+review = excluded.review, movie_rating = excluded.movie_rating, quality = excluded.quality,
+mentioned_titles = excluded.mentioned_titles, vanished_at = excluded.vanished_at, version = review.version + 1
+WHERE review.version = ?;`,
+		r.ID, r.MovieID, r.Source, r.URL, r.Review, r.MovieRating, r.Quality, string(titles), sql.NullTime{Time: r.VanishedAt, Valid: !r.VanishedAt.IsZero()}, r.Version)
+	if err != nil {
+		return rr.db.Error(err)
+	}
+	if err := checkUpdated(AuditTableReview, r.ID, res); err != nil {
+		return rr.db.Error(err)
+	}
+	if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+		return rr.db.Error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return rr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (rr *SQLReviewRepository) FindOne(ctx context.Context, id string) (Review, error) {
+	return rr.queryOne(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE id=? AND deleted_at IS NULL`, id)
+}
+
+func (rr *SQLReviewRepository) FindByMovieID(ctx context.Context, movieID string) ([]Review, error) {
+	return rr.query(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE movie_id=? AND deleted_at IS NULL`, movieID)
+}
+
+func (rr *SQLReviewRepository) FindNextUnrated(ctx context.Context) (Review, error) {
+	return rr.queryOne(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE quality=0 AND deleted_at IS NULL
+LIMIT 1`)
+}
+
+func (rr *SQLReviewRepository) FindUnrated(ctx context.Context) ([]Review, error) {
+	return rr.query(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE quality=0 AND deleted_at IS NULL`)
+}
+
+func (rr *SQLReviewRepository) FindNextNoTitles(ctx context.Context) (Review, error) {
+	return rr.queryOne(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE mentioned_titles='{}' AND deleted_at IS NULL
+LIMIT 1`)
+}
+
+func (rr *SQLReviewRepository) FindNoTitles(ctx context.Context) ([]Review, error) {
+	return rr.query(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE mentioned_titles='{}' AND deleted_at IS NULL`)
+}
+
+func (rr *SQLReviewRepository) FindAll(ctx context.Context) ([]Review, error) {
+	return rr.query(ctx, `
+SELECT id, movie_id, source, url, review, movie_rating, quality, mentioned_titles, version, vanished_at
+FROM review
+WHERE deleted_at IS NULL`)
+}
+
+func (rr *SQLReviewRepository) DeleteByMovieID(ctx context.Context, id string) error {
+	reviews, err := rr.FindByMovieID(ctx, id)
+	if err != nil {
+		return err
+	}
+	revisions := make([]Revision, 0, len(reviews))
+	for _, r := range reviews {
+		rev, err := newRevision(AuditTableReview, r.ID, actorOf(ctx, rr.db.actor), r, nil)
+		if err != nil {
+			return err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	tx, err := rr.db.begin(ctx)
+	if err != nil {
+		return rr.db.Error(err)
+	}
+	defer tx.Rollback()
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	if _, err := tx.ExecContext(ctx, `UPDATE review SET deleted_at=? WHERE movie_id=? AND deleted_at IS NULL`, deletedAt, id); err != nil {
+		return rr.db.Error(err)
+	}
+	for _, rev := range revisions {
+		if err := insertRevision(ctx, tx, insertAudit, rev); err != nil {
+			return rr.db.Error(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return rr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (rr *SQLReviewRepository) queryOne(ctx context.Context, query string, args ...any) (Review, error) {
+	row := rr.db.QueryRowContext(ctx, query, args...)
+	if row.Err() != nil {
+		return Review{}, rr.db.Error(row.Err())
+	}
+
+	r, err := rr.scan(row)
+	if err != nil {
+		return Review{}, err
+	}
+
+	return r, nil
+}
+
+func (rr *SQLReviewRepository) query(ctx context.Context, query string, args ...any) ([]Review, error) {
+	rows, err := rr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, rr.db.Error(err)
+	}
+	defer rows.Close()
+
+	reviews := make([]Review, 0)
+	for rows.Next() {
+		r, err := rr.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+
+	return reviews, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (rr *SQLReviewRepository) scan(row scanner) (Review, error) {
+	r := Review{}
+	var titles string
+	var vanishedAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.MovieID, &r.Source, &r.URL, &r.Review, &r.MovieRating, &r.Quality, &titles, &r.Version, &vanishedAt); err != nil {
+		return Review{}, rr.db.Error(err)
+	}
+	r.VanishedAt = vanishedAt.Time
+	if err := json.Unmarshal([]byte(titles), &r.Mentions); err != nil {
+		return Review{}, err
+	}
+
+	return r, nil
+}
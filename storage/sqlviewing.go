@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type SQLViewingRepository struct {
+	db *SQL
+}
+
+func NewSQLViewingRepository(db *SQL) *SQLViewingRepository {
+	return &SQLViewingRepository{
+		db: db,
+	}
+}
+
+func (vr *SQLViewingRepository) Store(ctx context.Context, v Viewing) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+
+	if _, err := vr.db.ExecContext(ctx, `INSERT INTO viewing (id, movie_id, watched_on, rating, notes, location)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE
+SET
+  movie_id = excluded.movie_id,
+  watched_on = excluded.watched_on,
+  rating = excluded.rating,
+  notes = excluded.notes,
+  location = excluded.location;`,
+		v.ID, v.MovieID, v.WatchedOn, v.Rating, v.Notes, v.Location); err != nil {
+		return vr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (vr *SQLViewingRepository) Delete(ctx context.Context, id string) error {
+	if _, err := vr.db.ExecContext(ctx, `DELETE FROM viewing WHERE id=?`, id); err != nil {
+		return vr.db.Error(err)
+	}
+
+	return nil
+}
+
+func (vr *SQLViewingRepository) FindOne(ctx context.Context, id string) (Viewing, error) {
+	row := vr.db.QueryRowContext(ctx, `
+SELECT id, movie_id, watched_on, rating, notes, location
+FROM viewing
+WHERE id=? AND deleted_at IS NULL`, id)
+	if row.Err() != nil {
+		return Viewing{}, vr.db.Error(row.Err())
+	}
+
+	v := Viewing{}
+	if err := row.Scan(&v.ID, &v.MovieID, &v.WatchedOn, &v.Rating, &v.Notes, &v.Location); err != nil {
+		return Viewing{}, vr.db.Error(err)
+	}
+
+	return v, nil
+}
+
+func (vr *SQLViewingRepository) FindByMovieID(ctx context.Context, movieID string) ([]Viewing, error) {
+	return vr.query(ctx, `
+SELECT id, movie_id, watched_on, rating, notes, location
+FROM viewing
+WHERE movie_id=? AND deleted_at IS NULL
+ORDER BY watched_on DESC NULLS LAST, id`, movieID)
+}
+
+func (vr *SQLViewingRepository) FindAll(ctx context.Context) ([]Viewing, error) {
+	return vr.query(ctx, `
+SELECT id, movie_id, watched_on, rating, notes, location
+FROM viewing
+WHERE deleted_at IS NULL
+ORDER BY watched_on DESC NULLS LAST, id`)
+}
+
+func (vr *SQLViewingRepository) query(ctx context.Context, query string, args ...any) ([]Viewing, error) {
+	rows, err := vr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, vr.db.Error(err)
+	}
+	defer rows.Close()
+
+	viewings := make([]Viewing, 0)
+	for rows.Next() {
+		v := Viewing{}
+		if err := rows.Scan(&v.ID, &v.MovieID, &v.WatchedOn, &v.Rating, &v.Notes, &v.Location); err != nil {
+			return nil, vr.db.Error(err)
+		}
+		viewings = append(viewings, v)
+	}
+
+	return viewings, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+const (
+	TypePostgres = "postgres"
+	TypeSQLite   = "sqlite"
+	TypeMemory   = "memory"
+
+	defaultSQLitePath = "emdb.db"
+)
+
+type Config struct {
+	Type     string
+	Host     string
+	Name     string
+	User     string
+	Password string
+	Path     string
+	// Actor is the name that is recorded with every change.
+	Actor string
+	// AutoMigrate applies the pending migrations when the database is
+	// opened.
+	AutoMigrate bool
+}
+
+func ConfigFromEnv() Config {
+	conf := Config{
+		Type:     os.Getenv("EMDB_DB_TYPE"),
+		Host:     os.Getenv("EMDB_DB_HOST"),
+		Name:     os.Getenv("EMDB_DB_NAME"),
+		User:     os.Getenv("EMDB_DB_USER"),
+		Password: os.Getenv("EMDB_DB_PASSWORD"),
+		Path:     os.Getenv("EMDB_DB_PATH"),
+		Actor:    os.Getenv("EMDB_ACTOR"),
+	}
+	if conf.Type == "" {
+		conf.Type = TypePostgres
+	}
+	if conf.Path == "" {
+		conf.Path = defaultSQLitePath
+	}
+	if conf.Actor == "" {
+		conf.Actor = defaultActor()
+	}
+	// on unless switched off, so a new install works right away
+	conf.AutoMigrate = os.Getenv("EMDB_AUTO_MIGRATE") != "false"
+
+	return conf
+}
+
+func (conf Config) connStr() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", conf.Host, conf.User, conf.Password, conf.Name)
+}
+
+// Backend is a database that can hand out the repositories that are
+// stored in it.
+type Backend interface {
+	Movies() MovieRepository
+	Reviews() ReviewRepository
+	Viewings() ViewingRepository
+	Lists() ListRepository
+	Audit() AuditRepository
+	Images() ImageRepository
+	// InTx runs fn as a unit of work: everything done with the context fn
+	// gets is kept when fn returns nil and undone otherwise.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func Open(conf Config) (Backend, error) {
+	switch conf.Type {
+	case TypePostgres, TypeSQLite:
+		var db *SQL
+		var err error
+		if conf.Type == TypePostgres {
+			db, err = openPostgres(conf.connStr())
+		} else {
+			db, err = openSQLite(conf.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if conf.AutoMigrate {
+			if err := db.Migrator().Up(); err != nil {
+				return nil, err
+			}
+		}
+		db.actor = conf.Actor
+		return db, nil
+	case TypeMemory:
+		mem := NewMemory()
+		mem.actor = conf.Actor
+		return mem, nil
+	default:
+		return nil, fmt.Errorf("unknown database type: %s", conf.Type)
+	}
+}
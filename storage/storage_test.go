@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+// newTestSQLiteBackend returns a SQLite database with all migrations applied.
+func newTestSQLiteBackend(t *testing.T) *SQL {
+	t.Helper()
+	db := newTestSQLite(t)
+	if err := db.Migrator().Up(); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	return db
+}
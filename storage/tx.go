@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// txKey marks the transaction of a unit of work in a context. It includes the
+// database, so a transaction is never used for another database.
+type txKey struct {
+	db *sql.DB
+}
+
+// querier runs statements, either directly on the database or in a
+// transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txn is the transaction of a single repository method. If the method is
+// called within a unit of work, it uses the transaction of that unit and
+// leaves committing and rolling back to it.
+type txn struct {
+	*sql.Tx
+	nested bool
+	rebind func(query string) string
+}
+
+func (t *txn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.rebind(query), args...)
+}
+
+func (t *txn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, t.rebind(query), args...)
+}
+
+func (t *txn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.rebind(query), args...)
+}
+
+func (t *txn) Commit() error {
+	if t.nested {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *txn) Rollback() error {
+	if t.nested {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// conn returns the transaction of the unit of work in the context, or the
+// database if there is none.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{db}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// begin starts the transaction of a repository method. Its queries go
+// through rebind.
+func begin(ctx context.Context, db *sql.DB, rebind func(string) string) (*txn, error) {
+	if tx, ok := ctx.Value(txKey{db}).(*sql.Tx); ok {
+		return &txn{Tx: tx, nested: true, rebind: rebind}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txn{Tx: tx, rebind: rebind}, nil
+}
+
+// inTx runs fn as a unit of work. A unit of work within another one becomes
+// part of it. Failures of the database itself go through classify.
+func inTx(ctx context.Context, db *sql.DB, classify func(error) error, fn func(ctx context.Context) error) error {
+	tx, err := begin(ctx, db, nil)
+	if err != nil {
+		return classify(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{db}, tx.Tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return classify(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package storage
+
+import "context"
+
+// Viewing is one time a movie was watched.
+type Viewing struct {
+	ID        string `json:"id"`
+	MovieID   string `json:"movieID"`
+	WatchedOn Date   `json:"watchedOn"`
+	Rating    int    `json:"rating"`
+	Notes     string `json:"notes"`
+	Location  string `json:"location"`
+}
+
+type ViewingRepository interface {
+	Store(ctx context.Context, v Viewing) error
+	Delete(ctx context.Context, id string) error
+	FindOne(ctx context.Context, id string) (Viewing, error)
+	FindByMovieID(ctx context.Context, movieID string) ([]Viewing, error)
+	// FindAll returns the diary, the most recent viewing first.
+	FindAll(ctx context.Context) ([]Viewing, error)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,28 +13,38 @@ import (
 )
 
 func main() {
+	demo := flag.Bool("demo", false, "use an in-memory database with example data")
+	flag.Parse()
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	tuiLogger := tui.NewLogger()
-	tmdb, err := client.NewTMDB(os.Getenv("TMDB_API_KEY"))
+	tmdbKey := os.Getenv("TMDB_API_KEY")
+	if *demo && tmdbKey == "" {
+		// searching will fail, but the rest works without tmdb
+		tmdbKey = "demo"
+	}
+	tmdb, err := client.NewTMDB(tmdbKey)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	dbHost := os.Getenv("EMDB_DB_HOST")
-	dbName := os.Getenv("EMDB_DB_NAME")
-	dbUser := os.Getenv("EMDB_DB_USER")
-	dbPassword := os.Getenv("EMDB_DB_PASSWORD")
-	pgConnStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPassword, dbName)
-	dbPostgres, err := storage.NewPostgres(pgConnStr)
+	var db storage.Backend
+	if *demo {
+		db = storage.NewDemo()
+	} else {
+		db, err = storage.Open(storage.ConfigFromEnv())
+		if err != nil {
+			fmt.Printf("could not open database: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+	jobQueue, err := job.NewJobQueue(db, logger)
 	if err != nil {
-		fmt.Printf("could not create new postgres repo: %s", err.Error())
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	movieRepo := storage.NewMovieRepository(dbPostgres)
-	reviewRepo := storage.NewReviewRepository(dbPostgres)
-	jobQueue := job.NewJobQueue(dbPostgres, logger)
 
-	p, err := tui.New(movieRepo, reviewRepo, jobQueue, tmdb, tuiLogger)
+	p, err := tui.New(db, jobQueue, tmdb, tuiLogger)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
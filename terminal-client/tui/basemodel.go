@@ -12,9 +12,13 @@ import (
 )
 
 type baseModel struct {
-	movieRepo   *storage.MovieRepository
-	reviewRepo  *storage.ReviewRepository
-	jobQueue    *job.JobQueue
+	db          storage.Backend
+	movieRepo   storage.MovieRepository
+	reviewRepo  storage.ReviewRepository
+	viewingRepo storage.ViewingRepository
+	listRepo    storage.ListRepository
+	auditRepo   storage.AuditRepository
+	jobQueue    job.JobQueue
 	tmdb        *client.TMDB
 	tabs        *TabSet
 	initialized bool
@@ -24,13 +28,17 @@ type baseModel struct {
 	contentSize tea.WindowSizeMsg
 }
 
-func NewBaseModel(movieRepo *storage.MovieRepository, reviewRepo *storage.ReviewRepository, jobQueue *job.JobQueue, tmdb *client.TMDB, logger *Logger) (tea.Model, tea.Cmd) {
+func NewBaseModel(db storage.Backend, jobQueue job.JobQueue, tmdb *client.TMDB, logger *Logger) (tea.Model, tea.Cmd) {
 	logViewport := viewport.New(0, 0)
 	logViewport.KeyMap = viewport.KeyMap{}
 
 	m := baseModel{
-		movieRepo:   movieRepo,
-		reviewRepo:  reviewRepo,
+		db:          db,
+		movieRepo:   db.Movies(),
+		reviewRepo:  db.Reviews(),
+		viewingRepo: db.Viewings(),
+		listRepo:    db.Lists(),
+		auditRepo:   db.Audit(),
 		jobQueue:    jobQueue,
 		tmdb:        tmdb,
 		tabs:        NewTabSet(),
@@ -59,15 +67,27 @@ func (m baseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.windowSize = msg
 		if !m.initialized {
 			var emdbTab, tmdbTab tea.Model
-			emdbTab, cmd = NewTabEMDB(m.movieRepo, m.logger)
+			emdbTab, cmd = NewTabEMDB(m.db, m.jobQueue, m.logger)
 			cmds = append(cmds, cmd)
-			tmdbTab, cmd = NewTabTMDB(m.movieRepo, m.jobQueue, m.tmdb, m.logger)
+			tmdbTab, cmd = NewTabTMDB(m.db, m.jobQueue, m.tmdb, m.logger)
 			cmds = append(cmds, cmd)
-			reviewTab, cmd := NewTabReview(m.reviewRepo, m.logger)
+			reviewTab, cmd := NewTabReview(m.reviewRepo, m.auditRepo, m.logger)
+			cmds = append(cmds, cmd)
+			diaryTab, cmd := NewTabDiary(m.movieRepo, m.viewingRepo, m.logger)
+			cmds = append(cmds, cmd)
+			watchlistTab, cmd := NewTabWatchlist(m.movieRepo, m.logger)
+			cmds = append(cmds, cmd)
+			listsTab, cmd := NewTabLists(m.movieRepo, m.listRepo, m.logger)
+			cmds = append(cmds, cmd)
+			trashTab, cmd := NewTabTrash(m.movieRepo, m.jobQueue, m.logger)
 			cmds = append(cmds, cmd)
 			m.tabs.AddTab("emdb", "Watched movies", emdbTab)
+			m.tabs.AddTab("watchlist", "Watchlist", watchlistTab)
+			m.tabs.AddTab("diary", "Diary", diaryTab)
+			m.tabs.AddTab("lists", "Lists", listsTab)
 			m.tabs.AddTab("review", "Review", reviewTab)
 			m.tabs.AddTab("tmdb", "TMDB", tmdbTab)
+			m.tabs.AddTab("trash", "Trash", trashTab)
 			m.initialized = true
 		}
 		m.Log(fmt.Sprintf("new window size: %dx%d", msg.Width, msg.Height))
@@ -79,8 +99,46 @@ func (m baseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.tabs.Update(tabSize))
 	case NewMovie:
 		m.Log(fmt.Sprintf("imported movie %s", msg.m.Title))
+		if msg.m.Status == storage.StatusWantToWatch {
+			m.tabs.Select("watchlist")
+			cmds = append(cmds, FetchWatchlist(m.movieRepo))
+			break
+		}
+		m.tabs.Select("emdb")
+		cmds = append(cmds, FetchMovieList(m.movieRepo))
+	case PromotedMovie:
+		m.tabs.Select("emdb")
+		cmds = append(cmds, m.tabs.Update(EditMovie(msg)))
+	case NewViewing:
+		m.Log(fmt.Sprintf("added viewing on %s", msg.WatchedOn))
+		m.tabs.Select("diary")
+		cmds = append(cmds, FetchViewingList(m.movieRepo, m.viewingRepo))
+	case TrashChanged:
+		// movies are moved to the trash from the emdb tab
+		cmds = append(cmds, FetchMovieList(m.movieRepo), FetchTrash(m.movieRepo), FetchViewingList(m.movieRepo, m.viewingRepo))
+	case RestoredMovie:
+		m.Log(fmt.Sprintf("restored movie %s from the trash", msg.Title))
+		cmds = append(cmds, FetchTrash(m.movieRepo), FetchViewingList(m.movieRepo, m.viewingRepo))
+		if msg.Status == storage.StatusWantToWatch {
+			m.tabs.Select("watchlist")
+			cmds = append(cmds, FetchWatchlist(m.movieRepo))
+			break
+		}
 		m.tabs.Select("emdb")
 		cmds = append(cmds, FetchMovieList(m.movieRepo))
+	case MoviePage, TMDBRefresh, TMDBRefreshed:
+		cmds = append(cmds, m.tabs.UpdateTab("emdb", msg))
+	case Trash:
+		cmds = append(cmds, m.tabs.UpdateTab("trash", msg))
+	case Viewings:
+		cmds = append(cmds, m.tabs.UpdateTab("diary", msg))
+	case Watchlist:
+		cmds = append(cmds, m.tabs.UpdateTab("watchlist", msg))
+	case ListsChanged:
+		cmds = append(cmds, FetchLists(m.movieRepo, m.listRepo))
+	case Lists:
+		// lists can be changed from other tabs
+		cmds = append(cmds, m.tabs.UpdateTab("lists", msg))
 	case error:
 		m.Log(fmt.Sprintf("ERROR: %s", msg.Error()))
 	default:
@@ -2,24 +2,33 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"go-mod.ewintr.nl/emdb/storage"
 	"github.com/charmbracelet/bubbles/list"
 )
 
 type Movie struct {
-	m storage.Movie
+	m       storage.Movie
+	snippet string
 }
 
+// FilterValue includes the tags, so the list can be filtered on them.
 func (m Movie) FilterValue() string {
-	return m.m.Title
+	return strings.Join(append([]string{m.m.Title}, m.m.Tags...), " ")
 }
 
 func (m Movie) Title() string {
+	if !m.m.DeletedAt.IsZero() {
+		return fmt.Sprintf("%s (%d), in the trash", m.m.Title, m.m.Year)
+	}
 	return fmt.Sprintf("%s (%d)", m.m.Title, m.m.Year)
 }
 
 func (m Movie) Description() string {
+	if m.snippet != "" {
+		return viewSnippet(m.snippet)
+	}
 	return fmt.Sprintf("%s", m.m.Summary)
 }
 
@@ -32,3 +41,66 @@ func (ms Movies) listItems() []list.Item {
 	}
 	return items
 }
+
+// MoviePage is a page of watched movies, the most recent first. Cursor is
+// the one the page was fetched with, so it is empty for the first page.
+type MoviePage struct {
+	page   storage.MoviePage
+	cursor string
+}
+
+// listItems puts the most recent movie at the bottom, where the list starts.
+func (mp MoviePage) listItems() []list.Item {
+	items := []list.Item{}
+	for i := len(mp.page.Movies) - 1; i >= 0; i-- {
+		items = append(items, Movie{m: mp.page.Movies[i]})
+	}
+	return items
+}
+
+type SearchHits []storage.SearchHit
+
+func (hs SearchHits) listItems() []list.Item {
+	items := []list.Item{}
+	for _, h := range hs {
+		items = append(items, Movie{m: h.Movie, snippet: h.Snippet})
+	}
+	return items
+}
+
+// viewSnippet puts the snippet on one line and highlights the matches.
+func viewSnippet(s string) string {
+	parts := strings.Split(strings.Join(strings.Fields(s), " "), storage.SnippetStart)
+	for i := 1; i < len(parts); i++ {
+		match, rest, _ := strings.Cut(parts[i], storage.SnippetStop)
+		parts[i] = focusedStyle.Render(match) + rest
+	}
+	return strings.Join(parts, "")
+}
+
+type WatchlistMovie struct {
+	m storage.Movie
+}
+
+func (m WatchlistMovie) FilterValue() string {
+	return m.m.Title
+}
+
+func (m WatchlistMovie) Title() string {
+	return fmt.Sprintf("%s (%d)", m.m.Title, m.m.Year)
+}
+
+func (m WatchlistMovie) Description() string {
+	if m.m.RecommendedBy == "" {
+		return fmt.Sprintf("priority %d", m.m.Priority)
+	}
+	return fmt.Sprintf("priority %d, recommended by %s", m.m.Priority, m.m.RecommendedBy)
+}
+
+func (ms Watchlist) listItems() []list.Item {
+	items := []list.Item{}
+	for _, m := range ms {
+		items = append(items, WatchlistMovie{m: m})
+	}
+	return items
+}
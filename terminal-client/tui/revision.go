@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+var revisionValueLength = 30
+
+type Revision struct {
+	r storage.Revision
+}
+
+func (r Revision) FilterValue() string {
+	return r.r.Actor
+}
+
+func (r Revision) Title() string {
+	return fmt.Sprintf("%s by %s", r.r.ChangedAt.Local().Format("2006-01-02 15:04"), r.r.Actor)
+}
+
+func (r Revision) Description() string {
+	changes := make([]string, 0, len(r.r.Changes))
+	for _, c := range r.r.Changes {
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", c.Field, viewRevisionValue(c.Old), viewRevisionValue(c.New)))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// viewRevisionValue shows a short version of a JSON encoded value.
+func viewRevisionValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	var s string
+	if err := json.Unmarshal([]byte(v), &s); err == nil {
+		v = fmt.Sprintf("%q", s)
+	}
+	if r := []rune(v); len(r) > revisionValueLength {
+		v = string(r[:revisionValueLength]) + "…"
+	}
+	return v
+}
+
+type Revisions []storage.Revision
+
+func (rs Revisions) listItems() []list.Item {
+	items := []list.Item{}
+	for _, r := range rs {
+		items = append(items, Revision{r: r})
+	}
+	return items
+}
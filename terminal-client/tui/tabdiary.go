@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type StoredViewing struct{}
+
+type tabDiary struct {
+	initialized    bool
+	movieRepo      storage.MovieRepository
+	viewingRepo    storage.ViewingRepository
+	mode           string
+	colWidth       int
+	colHeight      int
+	list           list.Model
+	formLabels     []string
+	inputWatchedOn textinput.Model
+	inputRating    textinput.Model
+	inputLocation  textinput.Model
+	inputNotes     textarea.Model
+	formFocus      int
+	logger         *Logger
+}
+
+func NewTabDiary(movieRepo storage.MovieRepository, viewingRepo storage.ViewingRepository, logger *Logger) (tea.Model, tea.Cmd) {
+	del := list.NewDefaultDelegate()
+	list := list.New([]list.Item{}, del, 0, 0)
+	list.Title = "Diary"
+	list.SetShowHelp(false)
+
+	formLabels := []string{
+		"Watched on",
+		"Rating",
+		"Location",
+		"Notes",
+	}
+
+	inputWatchedOn := textinput.New()
+	inputWatchedOn.Prompt = ""
+	inputWatchedOn.Width = 50
+	inputWatchedOn.CharLimit = 500
+	inputWatchedOn.Placeholder = "yyyy-mm-dd, today or yesterday"
+	inputRating := textinput.New()
+	inputRating.Prompt = ""
+	inputRating.Width = 50
+	inputRating.CharLimit = 500
+	inputLocation := textinput.New()
+	inputLocation.Prompt = ""
+	inputLocation.Width = 50
+	inputLocation.CharLimit = 500
+	inputNotes := textarea.New()
+	inputNotes.SetWidth(50)
+	inputNotes.SetHeight(3)
+	inputNotes.CharLimit = 500
+
+	m := tabDiary{
+		movieRepo:      movieRepo,
+		viewingRepo:    viewingRepo,
+		logger:         logger,
+		mode:           "view",
+		list:           list,
+		formLabels:     formLabels,
+		inputWatchedOn: inputWatchedOn,
+		inputRating:    inputRating,
+		inputLocation:  inputLocation,
+		inputNotes:     inputNotes,
+	}
+
+	return m, FetchViewingList(movieRepo, viewingRepo)
+}
+
+func (m tabDiary) Init() tea.Cmd {
+	return nil
+}
+
+func (m tabDiary) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case TabSizeMsg:
+		if !m.initialized {
+			m.initialized = true
+		}
+		m.colWidth = msg.Width / 2
+		m.colHeight = msg.Height
+		m.list.SetSize(m.colWidth, msg.Height-4)
+		m.list, cmd = m.list.Update(msg)
+		cmds = append(cmds, cmd)
+	case Viewings:
+		m.logger.Log(fmt.Sprintf("found %d viewings in diary", len(msg)))
+		m.list.SetItems(msg.listItems())
+		m.UpdateForm()
+		m.list, cmd = m.list.Update(msg)
+		cmds = append(cmds, cmd)
+	case StoredViewing:
+		m.logger.Log("stored viewing, fetching diary")
+		cmds = append(cmds, FetchViewingList(m.movieRepo, m.viewingRepo))
+	case tea.KeyMsg:
+		switch m.mode {
+		case "delete":
+			m.mode = "view"
+			if msg.String() == "y" {
+				cmds = append(cmds, m.DeleteViewing())
+			}
+		case "edit":
+			switch msg.String() {
+			case "tab", "shift+tab", "up", "down":
+				cmds = append(cmds, m.NavigateForm(msg.String())...)
+			case "esc":
+				m.mode = "view"
+				m.blurForm()
+				m.UpdateForm()
+			case "enter":
+				if err := m.validateForm(); err != nil {
+					m.Log(fmt.Sprintf("ERROR: %s", err))
+					break
+				}
+				m.mode = "view"
+				m.blurForm()
+				cmds = append(cmds, m.StoreViewing())
+			default:
+				cmds = append(cmds, m.updateFormInputs(msg))
+			}
+		default:
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				return m, tea.Quit
+			case "right", "tab":
+				cmds = append(cmds, SelectNextTab())
+			case "left", "shift+tab":
+				cmds = append(cmds, SelectPrevTab())
+			case "up", "down":
+				m.list, cmd = m.list.Update(msg)
+				m.UpdateForm()
+				cmds = append(cmds, cmd)
+			case "e":
+				if _, ok := m.list.SelectedItem().(Viewing); !ok {
+					break
+				}
+				m.mode = "edit"
+				m.formFocus = 0
+				m.inputWatchedOn.PromptStyle = focusedStyle
+				m.inputWatchedOn.TextStyle = focusedStyle
+				cmds = append(cmds, m.inputWatchedOn.Focus())
+			case "d":
+				if _, ok := m.list.SelectedItem().(Viewing); ok {
+					m.mode = "delete"
+				}
+			}
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m tabDiary) View() string {
+	colLeft := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.list.View())
+	colRight := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.ViewForm())
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, colLeft, colRight)
+}
+
+func (m *tabDiary) UpdateForm() {
+	viewing, ok := m.list.SelectedItem().(Viewing)
+	if !ok {
+		return
+	}
+	m.inputWatchedOn.SetValue(viewing.v.WatchedOn.String())
+	m.inputRating.SetValue(fmt.Sprintf("%d", viewing.v.Rating))
+	m.inputLocation.SetValue(viewing.v.Location)
+	m.inputNotes.SetValue(viewing.v.Notes)
+}
+
+func (m *tabDiary) updateFormInputs(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch m.formFocus {
+	case 0:
+		m.inputWatchedOn, cmd = m.inputWatchedOn.Update(msg)
+	case 1:
+		m.inputRating, cmd = m.inputRating.Update(msg)
+	case 2:
+		m.inputLocation, cmd = m.inputLocation.Update(msg)
+	case 3:
+		m.inputNotes, cmd = m.inputNotes.Update(msg)
+	}
+	return cmd
+}
+
+func (m *tabDiary) NavigateForm(key string) []tea.Cmd {
+	var cmds []tea.Cmd
+	if key == "up" || key == "shift+tab" {
+		m.formFocus--
+	} else {
+		m.formFocus++
+	}
+	if m.formFocus >= len(m.formLabels) {
+		m.formFocus = 0
+	}
+	if m.formFocus < 0 {
+		m.formFocus = len(m.formLabels) - 1
+	}
+
+	m.blurForm()
+	switch m.formLabels[m.formFocus] {
+	case "Watched on":
+		m.inputWatchedOn.PromptStyle = focusedStyle
+		m.inputWatchedOn.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputWatchedOn.Focus())
+	case "Rating":
+		m.inputRating.PromptStyle = focusedStyle
+		m.inputRating.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputRating.Focus())
+	case "Location":
+		m.inputLocation.PromptStyle = focusedStyle
+		m.inputLocation.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputLocation.Focus())
+	case "Notes":
+		cmds = append(cmds, m.inputNotes.Focus())
+	}
+
+	return cmds
+}
+
+func (m *tabDiary) blurForm() {
+	m.inputWatchedOn.Blur()
+	m.inputRating.Blur()
+	m.inputLocation.Blur()
+	m.inputNotes.Blur()
+}
+
+func (m *tabDiary) ViewForm() string {
+	viewing, ok := m.list.SelectedItem().(Viewing)
+	if !ok {
+		return ""
+	}
+
+	labels := []string{
+		"Title: ",
+		"Year: ",
+		"Directors: ",
+	}
+	for _, l := range m.formLabels {
+		labels = append(labels, fmt.Sprintf("%s: ", l))
+	}
+
+	fields := []string{
+		viewing.movie.Title,
+		fmt.Sprintf("%d", viewing.movie.Year),
+		strings.Join(storage.PersonNames(viewing.movie.Directors), ", "),
+		m.inputWatchedOn.View(),
+		m.inputRating.View(),
+		m.inputLocation.View(),
+		m.inputNotes.View(),
+	}
+	if m.mode == "delete" {
+		labels = append(labels, "", "", "Delete: ")
+		fields = append(fields, "remove this viewing? (y/n)")
+	}
+
+	labelView := strings.Join(labels, "\n")
+	fieldsView := strings.Join(fields, "\n")
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, labelView, fieldsView)
+}
+
+// validateForm checks the input before anything is stored and replaces date
+// shortcuts with the date they stand for.
+func (m *tabDiary) validateForm() error {
+	watchedOn, err := parseWatchedOn(m.inputWatchedOn.Value())
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.Atoi(m.inputRating.Value()); err != nil {
+		return fmt.Errorf("rating cannot be converted to an int: %w", err)
+	}
+	m.inputWatchedOn.SetValue(watchedOn.String())
+
+	return nil
+}
+
+func (m *tabDiary) StoreViewing() tea.Cmd {
+	return func() tea.Msg {
+		updated, ok := m.list.SelectedItem().(Viewing)
+		if !ok {
+			return nil
+		}
+		var err error
+		if updated.v.WatchedOn, err = parseWatchedOn(m.inputWatchedOn.Value()); err != nil {
+			return err
+		}
+		if updated.v.Rating, err = strconv.Atoi(m.inputRating.Value()); err != nil {
+			return fmt.Errorf("rating cannot be converted to an int: %w", err)
+		}
+		updated.v.Location = m.inputLocation.Value()
+		updated.v.Notes = m.inputNotes.Value()
+		if err := m.viewingRepo.Store(context.Background(), updated.v); err != nil {
+			return err
+		}
+		return StoredViewing{}
+	}
+}
+
+func (m *tabDiary) DeleteViewing() tea.Cmd {
+	viewing, ok := m.list.SelectedItem().(Viewing)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := m.viewingRepo.Delete(context.Background(), viewing.v.ID); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("deleted viewing of %s on %s", viewing.movie.Title, viewing.v.WatchedOn))
+		return StoredViewing{}
+	}
+}
+
+func (m *tabDiary) Log(s string) {
+	m.logger.Log(s)
+}
+
+func FetchViewingList(movieRepo storage.MovieRepository, viewingRepo storage.ViewingRepository) tea.Cmd {
+	return func() tea.Msg {
+		vs, err := viewingRepo.FindAll(context.Background())
+		if err != nil {
+			return err
+		}
+		ms, err := movieRepo.FindAll(context.Background())
+		if err != nil {
+			return err
+		}
+		movies := make(map[string]storage.Movie, len(ms))
+		for _, m := range ms {
+			movies[m.ID] = m
+		}
+
+		viewings := make(Viewings, 0, len(vs))
+		for _, v := range vs {
+			viewings = append(viewings, Viewing{v: v, movie: movies[v.MovieID]})
+		}
+		return viewings
+	}
+}
@@ -1,45 +1,102 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"go-mod.ewintr.nl/emdb/job"
 	"go-mod.ewintr.nl/emdb/storage"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
 )
 
 var (
 	focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	blurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	noStyle      = lipgloss.NewStyle()
+
+	castLineCount = 5
+	searchLimit   = 100
+	moviePageSize = 200
+
+	// refreshPollInterval is how often the queue is checked while a
+	// refresh from TMDB is waiting for the worker.
+	refreshPollInterval = 2 * time.Second
+	// refreshLogLimit is how many changed movies are logged after a refresh
+	// of all movies.
+	refreshLogLimit = 20
+
+	// watchedStatuses are the movies of the "Watched movies" tab.
+	watchedStatuses = []storage.Status{storage.StatusWatched, storage.StatusAbandoned}
 )
 
 type UpdateForm tea.Msg
 type StoredMovie struct{}
+type NewViewing storage.Viewing
+type EditMovie string
+type ListsChanged struct{}
+
+// TMDBRefresh is a refresh from TMDB that the worker is still busy with.
+// Without a movie, it is the refresh of all movies. After is the last
+// revision that was made by TMDB before the refresh, so the changes of the
+// refresh are the ones after that.
+type TMDBRefresh struct {
+	movie storage.Movie
+	after string
+}
+
+// TMDBRefreshed holds what the refresh changed, the most recent first.
+// Titles has the titles of the changed movies and movie is the movie as
+// it was stored by the refresh of a single movie.
+type TMDBRefreshed struct {
+	movie     storage.Movie
+	revisions []storage.Revision
+	titles    map[string]string
+}
 
 type tabEMDB struct {
 	initialized    bool
-	movieRepo      *storage.MovieRepository
+	db             storage.Backend
+	movieRepo      storage.MovieRepository
+	viewingRepo    storage.ViewingRepository
+	listRepo       storage.ListRepository
+	auditRepo      storage.AuditRepository
+	jobQueue       job.JobQueue
 	mode           string
 	focused        string
 	colWidth       int
 	colHeight      int
 	list           list.Model
+	history        list.Model
 	formLabels     []string
 	inputWatchedOn textinput.Model
 	inputRating    textinput.Model
+	inputTags      textinput.Model
 	inputComment   textarea.Model
+	inputList      textinput.Model
+	inputSearch    textinput.Model
+	searchText     string
+	next           string
 	formFocus      int
+	editID         string
 	logger         *Logger
 }
 
-func NewTabEMDB(movieRepo *storage.MovieRepository, logger *Logger) (tea.Model, tea.Cmd) {
+func NewTabEMDB(db storage.Backend, jobQueue job.JobQueue, logger *Logger) (tea.Model, tea.Cmd) {
 	del := list.NewDefaultDelegate()
+	history := list.New([]list.Item{}, del, 0, 0)
+	history.Title = "History"
+	history.SetShowHelp(false)
+	history.SetFilteringEnabled(false)
 	list := list.New([]list.Item{}, del, 0, 0)
 	list.Title = "Movies"
 	list.SetShowHelp(false)
@@ -47,6 +104,7 @@ func NewTabEMDB(movieRepo *storage.MovieRepository, logger *Logger) (tea.Model,
 	formLabels := []string{
 		"Watched on",
 		"Rating",
+		"Tags",
 		"Comment",
 	}
 
@@ -54,29 +112,54 @@ func NewTabEMDB(movieRepo *storage.MovieRepository, logger *Logger) (tea.Model,
 	inputWatchedOn.Prompt = ""
 	inputWatchedOn.Width = 50
 	inputWatchedOn.CharLimit = 500
+	inputWatchedOn.Placeholder = "yyyy-mm-dd, today or yesterday"
 	inputRating := textinput.New()
 	inputRating.Prompt = ""
 	inputRating.Width = 50
 	inputRating.CharLimit = 500
+	inputTags := textinput.New()
+	inputTags.Prompt = ""
+	inputTags.Width = 50
+	inputTags.CharLimit = 500
+	inputTags.Placeholder = "comma separated"
 	inputComment := textarea.New()
 	inputComment.SetWidth(50)
 	inputComment.SetHeight(3)
 	inputComment.CharLimit = 500
+	inputList := textinput.New()
+	inputList.Prompt = ""
+	inputList.Width = 50
+	inputList.CharLimit = 500
+	inputList.Placeholder = "name of a new or existing list"
+	inputSearch := textinput.New()
+	inputSearch.Prompt = ""
+	inputSearch.Width = 50
+	inputSearch.CharLimit = 500
+	inputSearch.Placeholder = "words in title, summary, comment or reviews"
 
 	m := tabEMDB{
 		focused:        "form",
-		movieRepo:      movieRepo,
+		db:             db,
+		movieRepo:      db.Movies(),
+		viewingRepo:    db.Viewings(),
+		listRepo:       db.Lists(),
+		auditRepo:      db.Audit(),
+		jobQueue:       jobQueue,
 		logger:         logger,
 		mode:           "view",
 		list:           list,
+		history:        history,
 		formLabels:     formLabels,
 		inputWatchedOn: inputWatchedOn,
 		inputRating:    inputRating,
+		inputTags:      inputTags,
 		inputComment:   inputComment,
+		inputList:      inputList,
+		inputSearch:    inputSearch,
 	}
 
 	logger.Log("search emdb...")
-	return m, FetchMovieList(movieRepo)
+	return m, FetchMovieList(m.movieRepo)
 }
 
 func (m tabEMDB) Init() tea.Cmd {
@@ -94,25 +177,117 @@ func (m tabEMDB) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.colWidth = msg.Width / 2
 		m.colHeight = msg.Height
 		m.list.SetSize(m.colWidth, msg.Height-4)
+		m.history.SetSize(m.colWidth, msg.Height-4)
 		m.list, cmd = m.list.Update(msg)
 		cmds = append(cmds, cmd)
-	case Movies:
-		m.logger.Log(fmt.Sprintf("found %d movies in in emdb", len(msg)))
+	case MoviePage:
+		if msg.cursor != "" {
+			// older movies go on top, the selection stays on the same movie.
+			// A page that was asked for twice is only added once.
+			if msg.cursor != m.next || m.searchText != "" {
+				break
+			}
+			m.next = msg.page.Next
+			items := msg.listItems()
+			selected := m.list.Index() + len(items)
+			m.list.SetItems(append(items, m.list.Items()...))
+			m.list.Select(selected)
+			m.list.Title = fmt.Sprintf("Movies, %d of %d", len(m.list.Items()), msg.page.Total)
+			break
+		}
+		m.logger.Log(fmt.Sprintf("found %d movies in in emdb", msg.page.Total))
+		m.next = msg.page.Next
+		m.searchText = ""
+		m.list.Title = fmt.Sprintf("Movies, %d of %d", len(msg.page.Movies), msg.page.Total)
 		m.list.SetItems(msg.listItems())
-		m.list.Select(len(msg.listItems()) - 1)
+		m.list.Select(len(msg.page.Movies) - 1)
+		if m.editID != "" {
+			cmds = append(cmds, m.editMovie(m.editID))
+			m.editID = ""
+		}
 		m.UpdateForm()
 		m.list, cmd = m.list.Update(msg)
 		cmds = append(cmds, cmd)
+	case SearchHits:
+		m.logger.Log(fmt.Sprintf("found %d movies matching %q", len(msg), m.searchText))
+		m.list.Title = fmt.Sprintf("Movies matching %q", m.searchText)
+		m.list.SetItems(msg.listItems())
+		m.UpdateForm()
+	case EditMovie:
+		m.editID = string(msg)
+		cmds = append(cmds, FetchMovieList(m.movieRepo))
 	case StoredMovie:
 		m.logger.Log("stored movie, fetching movie list")
-		cmds = append(cmds, FetchMovieList(m.movieRepo))
+		cmds = append(cmds, m.refresh())
+	case TMDBRefresh:
+		cmds = append(cmds, WaitForTMDBRefresh(m.jobQueue, m.movieRepo, m.auditRepo, msg))
+	case TMDBRefreshed:
+		cmds = append(cmds, m.showTMDBRefresh(msg))
+	case Revisions:
+		m.logger.Log(fmt.Sprintf("found %d revisions", len(msg)))
+		m.history.SetItems(msg.listItems())
+		m.history.Select(0)
 	case tea.KeyMsg:
+		if m.list.SettingFilter() || (m.mode == "view" && m.list.IsFiltered() && msg.String() == "esc") {
+			m.list, cmd = m.list.Update(msg)
+			m.UpdateForm()
+			cmds = append(cmds, cmd)
+			break
+		}
 		switch m.mode {
+		case "delete":
+			m.mode = "view"
+			if msg.String() == "y" {
+				cmds = append(cmds, m.DeleteMovie())
+			}
+		case "history":
+			switch msg.String() {
+			case "esc":
+				m.mode = "view"
+			case "up", "down":
+				m.history, cmd = m.history.Update(msg)
+				cmds = append(cmds, cmd)
+			case "r":
+				m.mode = "view"
+				cmds = append(cmds, m.RestoreRevision())
+			}
+		case "search":
+			switch msg.String() {
+			case "esc":
+				m.mode = "view"
+				m.inputSearch.Blur()
+			case "enter":
+				m.mode = "view"
+				m.inputSearch.Blur()
+				m.searchText = strings.TrimSpace(m.inputSearch.Value())
+				m.list.Select(0)
+				cmds = append(cmds, m.refresh())
+			default:
+				m.inputSearch, cmd = m.inputSearch.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case "list":
+			switch msg.String() {
+			case "esc":
+				m.mode = "view"
+				m.inputList.Blur()
+			case "enter":
+				m.mode = "view"
+				m.inputList.Blur()
+				cmds = append(cmds, m.AddToList(m.inputList.Value()))
+			default:
+				m.inputList, cmd = m.inputList.Update(msg)
+				cmds = append(cmds, cmd)
+			}
 		case "edit":
 			switch msg.String() {
 			case "tab", "shift+tab", "up", "down":
 				cmds = append(cmds, m.NavigateForm(msg.String())...)
 			case "enter":
+				if err := m.validateForm(); err != nil {
+					m.Log(fmt.Sprintf("ERROR: %s", err))
+					break
+				}
 				m.mode = "view"
 				cmds = append(cmds, m.StoreMovie())
 			default:
@@ -120,7 +295,12 @@ func (m tabEMDB) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		default:
 			switch msg.String() {
-			case "ctrl+c", "q", "esc":
+			case "esc":
+				if m.searchText == "" {
+					return m, tea.Quit
+				}
+				cmds = append(cmds, FetchMovieList(m.movieRepo))
+			case "ctrl+c", "q":
 				return m, tea.Quit
 			case "right", "tab":
 				cmds = append(cmds, SelectNextTab())
@@ -129,7 +309,7 @@ func (m tabEMDB) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "up":
 				m.list, cmd = m.list.Update(msg)
 				m.UpdateForm()
-				cmds = append(cmds, cmd)
+				cmds = append(cmds, cmd, m.fetchOlder())
 			case "down":
 				m.list, cmd = m.list.Update(msg)
 				m.UpdateForm()
@@ -140,6 +320,38 @@ func (m tabEMDB) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.inputWatchedOn.PromptStyle = focusedStyle
 				m.inputWatchedOn.TextStyle = focusedStyle
 				cmds = append(cmds, m.inputWatchedOn.Focus())
+			case "v":
+				cmds = append(cmds, m.AddViewing())
+			case "d":
+				if _, ok := m.list.SelectedItem().(Movie); ok {
+					m.mode = "delete"
+				}
+			case "h":
+				movie, ok := m.list.SelectedItem().(Movie)
+				if !ok {
+					break
+				}
+				m.mode = "history"
+				m.history.SetItems([]list.Item{})
+				cmds = append(cmds, FetchHistory(m.auditRepo, storage.AuditTableMovie, movie.m.ID))
+			case "l":
+				if _, ok := m.list.SelectedItem().(Movie); !ok {
+					break
+				}
+				m.mode = "list"
+				m.inputList.SetValue("")
+				cmds = append(cmds, m.inputList.Focus())
+			case "t":
+				cmds = append(cmds, m.RefreshTMDB())
+			case "T":
+				cmds = append(cmds, m.RefreshAllTMDB())
+			case "s":
+				m.mode = "search"
+				m.inputSearch.SetValue(m.searchText)
+				cmds = append(cmds, m.inputSearch.Focus())
+			case "/":
+				m.list, cmd = m.list.Update(msg)
+				cmds = append(cmds, cmd)
 			}
 		}
 	}
@@ -152,10 +364,14 @@ func (m tabEMDB) View() string {
 		Width(m.colWidth).
 		Height(m.colHeight).
 		Render(m.list.View())
+	right := m.ViewForm()
+	if m.mode == "history" {
+		right = m.history.View()
+	}
 	colRight := lipgloss.NewStyle().
 		Width(m.colWidth).
 		Height(m.colHeight).
-		Render(m.ViewForm())
+		Render(right)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, colLeft, colRight)
 }
@@ -165,12 +381,29 @@ func (m *tabEMDB) UpdateForm() {
 	if !ok {
 		return
 	}
-	m.inputWatchedOn.SetValue(movie.m.WatchedOn)
+	m.inputWatchedOn.SetValue(movie.m.WatchedOn.String())
 	m.inputRating.SetValue(fmt.Sprintf("%d", movie.m.Rating))
+	m.inputTags.SetValue(strings.Join(movie.m.Tags, ", "))
 	m.inputComment.SetValue(movie.m.Comment)
 	m.Log(fmt.Sprintf("showing movie %s", movie.m.ID))
 }
 
+// editMovie selects the movie and opens the form with the rating focused.
+func (m *tabEMDB) editMovie(id string) tea.Cmd {
+	for i, item := range m.list.Items() {
+		if movie, ok := item.(Movie); ok && movie.m.ID == id {
+			m.list.Select(i)
+			break
+		}
+	}
+	m.UpdateForm()
+	m.mode = "edit"
+	m.formFocus = 0
+	m.blurForm()
+
+	return tea.Batch(m.NavigateForm("down")...)
+}
+
 func (m *tabEMDB) updateFormInputs(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
 
@@ -180,52 +413,61 @@ func (m *tabEMDB) updateFormInputs(msg tea.Msg) tea.Cmd {
 	case 1:
 		m.inputRating, cmd = m.inputRating.Update(msg)
 	case 2:
+		m.inputTags, cmd = m.inputTags.Update(msg)
+	case 3:
 		m.inputComment, cmd = m.inputComment.Update(msg)
 	}
 	return cmd
 }
 
 func (m *tabEMDB) NavigateForm(key string) []tea.Cmd {
-	order := []string{"Watched on", "Rating", "Comment"}
-
 	var cmds []tea.Cmd
 	if key == "up" || key == "shift+tab" {
 		m.formFocus--
 	} else {
 		m.formFocus++
 	}
-	if m.formFocus >= len(order) {
+	if m.formFocus >= len(m.formLabels) {
 		m.formFocus = 0
 	}
 	if m.formFocus < 0 {
-		m.formFocus = len(order) - 1
+		m.formFocus = len(m.formLabels) - 1
 	}
 
-	switch order[m.formFocus] {
+	m.blurForm()
+	switch m.formLabels[m.formFocus] {
 	case "Watched on":
 		m.inputWatchedOn.PromptStyle = focusedStyle
 		m.inputWatchedOn.TextStyle = focusedStyle
 		cmds = append(cmds, m.inputWatchedOn.Focus())
-		m.inputRating.Blur()
-		m.inputComment.Blur()
 	case "Rating":
 		m.inputRating.PromptStyle = focusedStyle
 		m.inputRating.TextStyle = focusedStyle
 		cmds = append(cmds, m.inputRating.Focus())
-		m.inputWatchedOn.Blur()
-		m.inputComment.Blur()
+	case "Tags":
+		m.inputTags.PromptStyle = focusedStyle
+		m.inputTags.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputTags.Focus())
 	case "Comment":
 		cmds = append(cmds, m.inputComment.Focus())
-		m.inputWatchedOn.Blur()
-		m.inputRating.Blur()
 	}
 
 	return cmds
 }
 
+func (m *tabEMDB) blurForm() {
+	m.inputWatchedOn.Blur()
+	m.inputRating.Blur()
+	m.inputTags.Blur()
+	m.inputComment.Blur()
+}
+
 func (m *tabEMDB) ViewForm() string {
 	movie, ok := m.list.SelectedItem().(Movie)
 	if !ok {
+		if m.mode == "search" {
+			return lipgloss.JoinHorizontal(lipgloss.Top, "Search: ", m.inputSearch.View())
+		}
 		return ""
 	}
 
@@ -233,8 +475,23 @@ func (m *tabEMDB) ViewForm() string {
 		"Title: ",
 		"English title: ",
 		"Year: ",
+		"Runtime: ",
+		"Genres: ",
+		"Countries: ",
+		"Language: ",
+		"TMDB rating: ",
+		"Tagline: ",
 		"Directors: ",
+		"Writers: ",
+		"Cinematography: ",
+		"Music: ",
+		"Editing: ",
+		"Cast: ",
 		"Summary: ",
+		"Recommended by: ",
+	}
+	if movie.m.Status == storage.StatusAbandoned {
+		labels = append(labels, "Status: ")
 	}
 	for _, l := range m.formLabels {
 		labels = append(labels, fmt.Sprintf("%s: ", l))
@@ -244,11 +501,37 @@ func (m *tabEMDB) ViewForm() string {
 		movie.m.Title,
 		movie.m.EnglishTitle,
 		fmt.Sprintf("%d", movie.m.Year),
-		strings.Join(movie.m.Directors, ","),
+		viewRuntime(movie.m.Runtime),
+		strings.Join(movie.m.Genres, ", "),
+		strings.Join(movie.m.Countries, ", "),
+		movie.m.OriginalLanguage,
+		viewVotes(movie.m.VoteAverage, movie.m.VoteCount),
+		movie.m.Tagline,
+		strings.Join(storage.PersonNames(movie.m.Directors), ", "),
+		strings.Join(storage.PersonNames(movie.m.People(storage.RoleWriter)), ", "),
+		strings.Join(storage.PersonNames(movie.m.People(storage.RoleCinematographer)), ", "),
+		strings.Join(storage.PersonNames(movie.m.People(storage.RoleComposer)), ", "),
+		strings.Join(storage.PersonNames(movie.m.People(storage.RoleEditor)), ", "),
+		viewCast(movie.m.Cast),
 		movie.m.Summary,
+		movie.m.RecommendedBy,
+	}
+	if movie.m.Status == storage.StatusAbandoned {
+		fields = append(fields, "abandoned")
 	}
 
-	fields = append(fields, m.inputWatchedOn.View(), m.inputRating.View(), m.inputComment.View())
+	fields = append(fields, m.inputWatchedOn.View(), m.inputRating.View(), m.inputTags.View(), m.inputComment.View())
+	switch m.mode {
+	case "list":
+		labels = append(labels, "", "", "Add to list: ")
+		fields = append(fields, m.inputList.View())
+	case "delete":
+		labels = append(labels, "", "", "Delete: ")
+		fields = append(fields, "move this movie to the trash? (y/n)")
+	case "search":
+		labels = append(labels, "", "", "Search: ")
+		fields = append(fields, m.inputSearch.View())
+	}
 
 	labelView := strings.Join(labels, "\n")
 	fieldsView := strings.Join(fields, "\n")
@@ -256,32 +539,393 @@ func (m *tabEMDB) ViewForm() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, labelView, fieldsView)
 }
 
+func viewCast(cast []storage.Credit) string {
+	if len(cast) > castLineCount {
+		cast = cast[:castLineCount]
+	}
+	lines := make([]string, 0, len(cast))
+	for _, c := range cast {
+		if c.Character == "" {
+			lines = append(lines, c.Person.Name)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s)", c.Person.Name, c.Character))
+	}
+
+	return strings.Join(lines, ", ")
+}
+
+// viewRuntime shows the runtime in hours and minutes.
+func viewRuntime(minutes int) string {
+	if minutes == 0 {
+		return ""
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+func viewVotes(average float64, count int) string {
+	if count == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%.1f (%d votes)", average, count)
+}
+
+// validateForm checks the input before anything is stored and replaces date
+// shortcuts with the date they stand for.
+func (m *tabEMDB) validateForm() error {
+	watchedOn, err := parseWatchedOn(m.inputWatchedOn.Value())
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.Atoi(m.inputRating.Value()); err != nil {
+		return fmt.Errorf("rating cannot be converted to an int: %w", err)
+	}
+	m.inputWatchedOn.SetValue(watchedOn.String())
+
+	return nil
+}
+
+// parseWatchedOn accepts a yyyy-mm-dd date and the shortcuts "today" and
+// "yesterday".
+func parseWatchedOn(s string) (storage.Date, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "today":
+		return storage.Today(), nil
+	case "yesterday":
+		return storage.Today().AddDays(-1), nil
+	default:
+		return storage.ParseDate(s)
+	}
+}
+
 func (m *tabEMDB) StoreMovie() tea.Cmd {
 	return func() tea.Msg {
 		updatedMovie := m.list.SelectedItem().(Movie)
-		updatedMovie.m.WatchedOn = m.inputWatchedOn.Value()
-		var err error
-		if updatedMovie.m.Rating, err = strconv.Atoi(m.inputRating.Value()); err != nil {
+		watchedOn, err := parseWatchedOn(m.inputWatchedOn.Value())
+		if err != nil {
+			return err
+		}
+		rating, err := strconv.Atoi(m.inputRating.Value())
+		if err != nil {
 			return fmt.Errorf("rating cannot be converted to an int: %w", err)
 		}
-		updatedMovie.m.Comment = m.inputComment.Value()
-		if err := m.movieRepo.Store(updatedMovie.m); err != nil {
+		tags := storage.ParseTags(m.inputTags.Value())
+		comment := m.inputComment.Value()
+		if err := storage.UpdateMovie(context.Background(), m.movieRepo, updatedMovie.m, func(mov *storage.Movie) {
+			mov.WatchedOn = watchedOn
+			mov.Rating = rating
+			mov.Tags = tags
+			mov.Comment = comment
+		}); err != nil {
 			return err
 		}
 		return StoredMovie{}
 	}
 }
 
+// AddViewing records that the selected movie was watched again today.
+func (m *tabEMDB) AddViewing() tea.Cmd {
+	movie, ok := m.list.SelectedItem().(Movie)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		v := storage.Viewing{
+			ID:        uuid.New().String(),
+			MovieID:   movie.m.ID,
+			WatchedOn: storage.Today(),
+			Rating:    movie.m.Rating,
+		}
+		if err := m.viewingRepo.Store(context.Background(), v); err != nil {
+			return err
+		}
+		return NewViewing(v)
+	}
+}
+
+// AddToList puts the selected movie at the end of the list with the name. The
+// list is created when it does not exist yet.
+func (m *tabEMDB) AddToList(name string) tea.Cmd {
+	movie, ok := m.list.SelectedItem().(Movie)
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		lists, err := m.listRepo.FindAll(context.Background())
+		if err != nil {
+			return err
+		}
+		l := storage.List{Name: name}
+		for _, existing := range lists {
+			if strings.EqualFold(existing.Name, name) {
+				l = existing
+				break
+			}
+		}
+		if slices.Contains(l.MovieIDs, movie.m.ID) {
+			m.logger.Log(fmt.Sprintf("%s is already on list %s", movie.m.Title, l.Name))
+			return nil
+		}
+		l.MovieIDs = append(l.MovieIDs, movie.m.ID)
+		if err := m.listRepo.Store(context.Background(), l); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("added %s to list %s", movie.m.Title, l.Name))
+		return ListsChanged{}
+	}
+}
+
+// DeleteMovie moves the selected movie to the trash.
+func (m *tabEMDB) DeleteMovie() tea.Cmd {
+	movie, ok := m.list.SelectedItem().(Movie)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := m.movieRepo.Delete(context.Background(), movie.m.ID); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("moved %s to the trash", movie.m.Title))
+		return TrashChanged{}
+	}
+}
+
+// RefreshTMDB leaves it to the worker to fetch the selected movie from TMDB
+// again. The changes are shown when the worker is done.
+func (m *tabEMDB) RefreshTMDB() tea.Cmd {
+	movie, ok := m.list.SelectedItem().(Movie)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		after, err := lastTMDBRevision(m.auditRepo)
+		if err != nil {
+			return err
+		}
+		if err := m.jobQueue.Enqueue(context.Background(), job.Job{ActionID: movie.m.ID, Action: job.ActionRefreshTMDB, Priority: job.PriorityHigh}); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("added job to refresh %s from tmdb", movie.m.Title))
+		return TMDBRefresh{movie: movie.m, after: after}
+	}
+}
+
+// RefreshAllTMDB adds a job that adds a refresh job for every movie.
+func (m *tabEMDB) RefreshAllTMDB() tea.Cmd {
+	return func() tea.Msg {
+		after, err := lastTMDBRevision(m.auditRepo)
+		if err != nil {
+			return err
+		}
+		if err := m.jobQueue.Enqueue(context.Background(), job.Job{Action: job.ActionRefreshAllTMDB, Priority: job.PriorityHigh}); err != nil {
+			return err
+		}
+		m.logger.Log("added job to refresh all movies from tmdb")
+		return TMDBRefresh{after: after}
+	}
+}
+
+// showTMDBRefresh logs what a refresh from TMDB changed. After the refresh
+// of the selected movie, its history is opened, with the refresh on top.
+func (m *tabEMDB) showTMDBRefresh(msg TMDBRefreshed) tea.Cmd {
+	if msg.movie.ID == "" {
+		m.logger.Log(fmt.Sprintf("refreshed all movies from tmdb, %d changed", len(msg.titles)))
+		for i, rev := range msg.revisions {
+			if i == refreshLogLimit {
+				m.logger.Log(fmt.Sprintf("  and %d more, see the history of the movies", len(msg.revisions)-i))
+				break
+			}
+			m.logger.Log(fmt.Sprintf("  %s: %s", msg.titles[rev.RowID], changedFields(rev)))
+		}
+		if len(msg.revisions) == 0 {
+			return nil
+		}
+		return m.refresh()
+	}
+
+	if len(msg.revisions) == 0 {
+		m.logger.Log(fmt.Sprintf("%s was already up to date with tmdb", msg.movie.Title))
+		return nil
+	}
+	m.logger.Log(fmt.Sprintf("refreshed %s from tmdb: %s", msg.movie.Title, changedFields(msg.revisions[0])))
+	for i, item := range m.list.Items() {
+		if movie, ok := item.(Movie); ok && movie.m.ID == msg.movie.ID {
+			m.list.SetItem(i, Movie{m: msg.movie, snippet: movie.snippet})
+		}
+	}
+	m.UpdateForm()
+	if selected, ok := m.list.SelectedItem().(Movie); !ok || selected.m.ID != msg.movie.ID || m.mode != "view" {
+		return nil
+	}
+	m.mode = "history"
+	m.history.SetItems([]list.Item{})
+	return FetchHistory(m.auditRepo, storage.AuditTableMovie, msg.movie.ID)
+}
+
+// RestoreRevision stores the selected movie as it was after the selected
+// revision.
+func (m *tabEMDB) RestoreRevision() tea.Cmd {
+	movie, ok := m.list.SelectedItem().(Movie)
+	if !ok {
+		return nil
+	}
+	rev, ok := m.history.SelectedItem().(Revision)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, err := storage.RestoreMovie(context.Background(), m.db, movie.m.ID, rev.r.ID); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("restored %s to the version of %s", movie.m.Title, rev.r.ChangedAt.Local().Format("2006-01-02 15:04")))
+		// the restored version may be in or out of the trash
+		return TrashChanged{}
+	}
+}
+
+// refresh fetches the movies again, or the hits if there is a search.
+func (m *tabEMDB) refresh() tea.Cmd {
+	if m.searchText == "" {
+		return FetchMovieList(m.movieRepo)
+	}
+	return SearchMovies(m.movieRepo, m.searchText)
+}
+
+// fetchOlder fetches the next page when the top of the list is reached.
+// Search results and filtered lists are not paged.
+func (m *tabEMDB) fetchOlder() tea.Cmd {
+	if m.next == "" || m.searchText != "" || m.list.IsFiltered() || m.list.Index() > 0 {
+		return nil
+	}
+	return FetchMoviePage(m.movieRepo, m.next)
+}
+
 func (m *tabEMDB) Log(s string) {
 	m.logger.Log(s)
 }
 
-func FetchMovieList(movieRepo *storage.MovieRepository) tea.Cmd {
+// FetchMovieList fetches the first page of watched movies.
+func FetchMovieList(movieRepo storage.MovieRepository) tea.Cmd {
+	return FetchMoviePage(movieRepo, "")
+}
+
+// FetchMoviePage fetches the page of watched movies that starts at the
+// cursor. The most recently watched movies come first.
+func FetchMoviePage(movieRepo storage.MovieRepository, cursor string) tea.Cmd {
+	return func() tea.Msg {
+		page, err := movieRepo.Query(context.Background(), storage.MovieQuery{
+			Statuses: watchedStatuses,
+			Sort:     []storage.Sort{{Field: storage.SortWatchedOn, Desc: true}},
+			Limit:    moviePageSize,
+			Cursor:   cursor,
+		})
+		if err != nil {
+			return err
+		}
+		return MoviePage{page: page, cursor: cursor}
+	}
+}
+
+func SearchMovies(movieRepo storage.MovieRepository, text string) tea.Cmd {
 	return func() tea.Msg {
-		ems, err := movieRepo.FindAll()
+		hits, err := movieRepo.Search(context.Background(), text, watchedStatuses, searchLimit)
+		if err != nil {
+			return err
+		}
+		return SearchHits(hits)
+	}
+}
+
+func FetchHistory(auditRepo storage.AuditRepository, table, rowID string) tea.Cmd {
+	return func() tea.Msg {
+		revs, err := auditRepo.FindByRow(context.Background(), table, rowID)
+		if err != nil {
+			return err
+		}
+		return Revisions(revs)
+	}
+}
+
+// WaitForTMDBRefresh checks the queue until the worker is done with the
+// refresh and then finds the revisions that it made.
+func WaitForTMDBRefresh(jobQueue job.JobQueue, movieRepo storage.MovieRepository, auditRepo storage.AuditRepository, refresh TMDBRefresh) tea.Cmd {
+	return tea.Tick(refreshPollInterval, func(time.Time) tea.Msg {
+		ctx := context.Background()
+		jobs, err := jobQueue.List(ctx)
 		if err != nil {
 			return err
 		}
-		return Movies(ems)
+		for _, j := range jobs {
+			if j.Status == "failed" {
+				continue
+			}
+			switch {
+			case refresh.movie.ID == "" && (j.Action == job.ActionRefreshAllTMDB || j.Action == job.ActionRefreshTMDB),
+				j.Action == job.ActionRefreshTMDB && j.ActionID == refresh.movie.ID:
+				return refresh
+			}
+		}
+
+		revisions, err := auditRepo.FindByActor(ctx, storage.AuditTableMovie, storage.ActorTMDB)
+		if err != nil {
+			return err
+		}
+		refreshed := TMDBRefreshed{
+			revisions: make([]storage.Revision, 0),
+			titles:    make(map[string]string),
+		}
+		for _, rev := range storage.NewerThan(revisions, refresh.after) {
+			if refresh.movie.ID != "" && rev.RowID != refresh.movie.ID {
+				continue
+			}
+			refreshed.revisions = append(refreshed.revisions, rev)
+			if _, ok := refreshed.titles[rev.RowID]; ok {
+				continue
+			}
+			movie, err := movieRepo.FindOne(ctx, rev.RowID)
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				// removed from the trash since
+				refreshed.titles[rev.RowID] = rev.RowID
+			case err != nil:
+				return err
+			default:
+				refreshed.titles[rev.RowID] = movie.Title
+			}
+		}
+		if refresh.movie.ID != "" {
+			if refreshed.movie, err = movieRepo.FindOne(ctx, refresh.movie.ID); err != nil {
+				return err
+			}
+		}
+
+		return refreshed
+	})
+}
+
+// lastTMDBRevision returns the id of the most recent change that was made
+// by a refresh from TMDB, or an empty id if there is none.
+func lastTMDBRevision(auditRepo storage.AuditRepository) (string, error) {
+	revisions, err := auditRepo.FindByActor(context.Background(), storage.AuditTableMovie, storage.ActorTMDB)
+	if err != nil || len(revisions) == 0 {
+		return "", err
+	}
+
+	return revisions[0].ID, nil
+}
+
+// changedFields lists the fields that the revision changed.
+func changedFields(rev storage.Revision) string {
+	fields := make([]string, 0, len(rev.Changes))
+	for _, c := range rev.Changes {
+		fields = append(fields, c.Field)
 	}
+
+	return strings.Join(fields, ", ")
 }
@@ -0,0 +1,325 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Lists struct {
+	lists  []storage.List
+	movies map[string]storage.Movie
+}
+
+type ListItem struct {
+	l storage.List
+}
+
+func (li ListItem) FilterValue() string {
+	return li.l.Name
+}
+
+func (li ListItem) Title() string {
+	return li.l.Name
+}
+
+func (li ListItem) Description() string {
+	if li.l.Description == "" {
+		return fmt.Sprintf("%d movies", len(li.l.MovieIDs))
+	}
+	return fmt.Sprintf("%d movies, %s", len(li.l.MovieIDs), li.l.Description)
+}
+
+type tabLists struct {
+	initialized bool
+	movieRepo   storage.MovieRepository
+	listRepo    storage.ListRepository
+	mode        string
+	colWidth    int
+	colHeight   int
+	lists       list.Model
+	entries     list.Model
+	movies      map[string]storage.Movie
+	selectedID  string
+	inputText   textinput.Model
+	logger      *Logger
+}
+
+func NewTabLists(movieRepo storage.MovieRepository, listRepo storage.ListRepository, logger *Logger) (tea.Model, tea.Cmd) {
+	lists := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	lists.Title = "Lists"
+	lists.SetShowHelp(false)
+	lists.SetFilteringEnabled(false)
+	entries := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	entries.Title = "Movies"
+	entries.SetShowHelp(false)
+	entries.SetFilteringEnabled(false)
+
+	inputText := textinput.New()
+	inputText.Prompt = ""
+	inputText.Width = 50
+	inputText.CharLimit = 500
+
+	m := tabLists{
+		movieRepo: movieRepo,
+		listRepo:  listRepo,
+		mode:      "lists",
+		lists:     lists,
+		entries:   entries,
+		movies:    make(map[string]storage.Movie),
+		inputText: inputText,
+		logger:    logger,
+	}
+
+	return m, FetchLists(movieRepo, listRepo)
+}
+
+func (m tabLists) Init() tea.Cmd {
+	return nil
+}
+
+func (m tabLists) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case TabSizeMsg:
+		if !m.initialized {
+			m.initialized = true
+		}
+		m.colWidth = msg.Width / 2
+		m.colHeight = msg.Height
+		m.lists.SetSize(m.colWidth, msg.Height-4)
+		m.entries.SetSize(m.colWidth, msg.Height-4)
+	case Lists:
+		m.logger.Log(fmt.Sprintf("found %d lists", len(msg.lists)))
+		m.movies = msg.movies
+		items := make([]list.Item, 0, len(msg.lists))
+		selected := m.lists.Index()
+		for i, l := range msg.lists {
+			items = append(items, ListItem{l: l})
+			if l.ID == m.selectedID {
+				selected = i
+			}
+		}
+		m.lists.SetItems(items)
+		m.lists.Select(selected)
+		m.updateEntries()
+	case tea.KeyMsg:
+		switch m.mode {
+		case "name", "description":
+			switch msg.String() {
+			case "esc":
+				m.mode = "lists"
+				m.inputText.Blur()
+			case "enter":
+				cmds = append(cmds, m.StoreText(m.mode, m.inputText.Value()))
+				m.mode = "lists"
+				m.inputText.Blur()
+			default:
+				m.inputText, cmd = m.inputText.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		case "entries":
+			switch msg.String() {
+			case "esc", "enter":
+				m.mode = "lists"
+			case "up", "down":
+				m.entries, cmd = m.entries.Update(msg)
+				cmds = append(cmds, cmd)
+			case "K":
+				cmds = append(cmds, m.MoveEntry(-1))
+			case "J":
+				cmds = append(cmds, m.MoveEntry(1))
+			case "x":
+				cmds = append(cmds, m.MoveEntry(0))
+			}
+		default:
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				return m, tea.Quit
+			case "right", "tab":
+				cmds = append(cmds, SelectNextTab())
+			case "left", "shift+tab":
+				cmds = append(cmds, SelectPrevTab())
+			case "up", "down":
+				m.lists, cmd = m.lists.Update(msg)
+				m.updateEntries()
+				cmds = append(cmds, cmd)
+			case "enter":
+				if len(m.entries.Items()) > 0 {
+					m.mode = "entries"
+				}
+			case "n":
+				m.selectedID = ""
+				m.mode = "name"
+				m.inputText.SetValue("")
+				cmds = append(cmds, m.inputText.Focus())
+			case "r", "e":
+				selected, ok := m.lists.SelectedItem().(ListItem)
+				if !ok {
+					break
+				}
+				m.mode = "name"
+				m.inputText.SetValue(selected.l.Name)
+				if msg.String() == "e" {
+					m.mode = "description"
+					m.inputText.SetValue(selected.l.Description)
+				}
+				cmds = append(cmds, m.inputText.Focus())
+			case "d":
+				cmds = append(cmds, m.DeleteList())
+			}
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m tabLists) View() string {
+	colLeft := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.lists.View())
+
+	right := m.entries.View()
+	switch m.mode {
+	case "name":
+		right = fmt.Sprintf("Name: %s", m.inputText.View())
+	case "description":
+		right = fmt.Sprintf("Description: %s", m.inputText.View())
+	}
+	colRight := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(right)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, colLeft, colRight)
+}
+
+// updateEntries shows the movies on the selected list.
+func (m *tabLists) updateEntries() {
+	selected, ok := m.lists.SelectedItem().(ListItem)
+	if !ok {
+		m.entries.SetItems([]list.Item{})
+		return
+	}
+	if selected.l.ID != m.selectedID {
+		m.entries.Select(0)
+	}
+	m.selectedID = selected.l.ID
+
+	items := make([]list.Item, 0, len(selected.l.MovieIDs))
+	for _, id := range selected.l.MovieIDs {
+		items = append(items, Movie{m: m.movies[id]})
+	}
+	m.entries.SetItems(items)
+}
+
+// StoreText sets the name or description of the selected list. A name
+// without a selected list creates a new one.
+func (m *tabLists) StoreText(field, value string) tea.Cmd {
+	value = strings.TrimSpace(value)
+	l := storage.List{}
+	if selected, ok := m.lists.SelectedItem().(ListItem); ok && m.selectedID != "" {
+		l = selected.l
+	}
+	switch field {
+	case "name":
+		if value == "" {
+			return nil
+		}
+		l.Name = value
+	case "description":
+		if l.ID == "" {
+			return nil
+		}
+		l.Description = value
+	}
+
+	return m.storeList(l)
+}
+
+// MoveEntry moves the selected movie up or down the list. A zero step
+// removes it from the list.
+func (m *tabLists) MoveEntry(step int) tea.Cmd {
+	selected, ok := m.lists.SelectedItem().(ListItem)
+	if !ok {
+		return nil
+	}
+	l := selected.l
+	l.MovieIDs = slices.Clone(l.MovieIDs)
+	i := m.entries.Index()
+	if i < 0 || i >= len(l.MovieIDs) {
+		return nil
+	}
+
+	switch {
+	case step == 0:
+		l.MovieIDs = slices.Delete(l.MovieIDs, i, i+1)
+	case i+step >= 0 && i+step < len(l.MovieIDs):
+		l.MovieIDs[i], l.MovieIDs[i+step] = l.MovieIDs[i+step], l.MovieIDs[i]
+		m.entries.Select(i + step)
+	default:
+		return nil
+	}
+	if len(l.MovieIDs) == 0 {
+		m.mode = "lists"
+	}
+
+	return m.storeList(l)
+}
+
+func (m *tabLists) DeleteList() tea.Cmd {
+	selected, ok := m.lists.SelectedItem().(ListItem)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := m.listRepo.Delete(context.Background(), selected.l.ID); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("deleted list %s", selected.l.Name))
+		return ListsChanged{}
+	}
+}
+
+func (m *tabLists) storeList(l storage.List) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.listRepo.Store(context.Background(), l); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("stored list %s", l.Name))
+		return ListsChanged{}
+	}
+}
+
+func FetchLists(movieRepo storage.MovieRepository, listRepo storage.ListRepository) tea.Cmd {
+	return func() tea.Msg {
+		ls, err := listRepo.FindAll(context.Background())
+		if err != nil {
+			return err
+		}
+		ms, err := movieRepo.FindAll(context.Background())
+		if err != nil {
+			return err
+		}
+		// movies in the trash stay on their lists until they are purged
+		trashed, err := movieRepo.FindDeleted(context.Background())
+		if err != nil {
+			return err
+		}
+		movies := make(map[string]storage.Movie, len(ms)+len(trashed))
+		for _, m := range append(ms, trashed...) {
+			movies[m.ID] = m
+		}
+
+		return Lists{lists: ls, movies: movies}
+	}
+}
@@ -1,11 +1,13 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -15,7 +17,8 @@ import (
 
 type tabReview struct {
 	initialized    bool
-	reviewRepo     *storage.ReviewRepository
+	reviewRepo     storage.ReviewRepository
+	auditRepo      storage.AuditRepository
 	width          int
 	height         int
 	mode           string
@@ -23,11 +26,12 @@ type tabReview struct {
 	reviewViewport viewport.Model
 	inputQuality   textinput.Model
 	inputMentions  textarea.Model
+	history        list.Model
 	formFocus      int
 	logger         *Logger
 }
 
-func NewTabReview(reviewRepo *storage.ReviewRepository, logger *Logger) (tea.Model, tea.Cmd) {
+func NewTabReview(reviewRepo storage.ReviewRepository, auditRepo storage.AuditRepository, logger *Logger) (tea.Model, tea.Cmd) {
 	reviewViewport := viewport.New(0, 0)
 	//reviewViewport.KeyMap = viewport.KeyMap{}
 
@@ -39,13 +43,19 @@ func NewTabReview(reviewRepo *storage.ReviewRepository, logger *Logger) (tea.Mod
 	inputMentions.SetWidth(30)
 	inputMentions.SetHeight(5)
 	inputMentions.CharLimit = 500
+	history := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	history.Title = "History"
+	history.SetShowHelp(false)
+	history.SetFilteringEnabled(false)
 
 	return &tabReview{
 		reviewRepo:     reviewRepo,
+		auditRepo:      auditRepo,
 		mode:           "view",
 		reviewViewport: reviewViewport,
 		inputQuality:   inputQuality,
 		inputMentions:  inputMentions,
+		history:        history,
 		logger:         logger,
 	}, nil
 }
@@ -66,8 +76,20 @@ func (m *tabReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.reviewViewport.Width = (m.width / 2) - 2
 		m.reviewViewport.Height = m.height - 2
+		m.history.SetSize(m.width/2, m.height-2)
 	case tea.KeyMsg:
 		switch m.mode {
+		case "history":
+			switch msg.String() {
+			case "esc":
+				m.mode = "view"
+			case "up", "down":
+				m.history, cmd = m.history.Update(msg)
+				cmds = append(cmds, cmd)
+			case "r":
+				m.mode = "view"
+				cmds = append(cmds, m.RestoreRevision())
+			}
 		case "edit":
 			switch msg.String() {
 			case "tab", "shift+tab", "up", "down":
@@ -97,6 +119,13 @@ func (m *tabReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.logger.Log("fetching next unrated review")
 				cmds = append(cmds, m.inputQuality.Focus())
 				cmds = append(cmds, FetchNextUnratedReview(m.reviewRepo))
+			case "h":
+				if m.selectedReview.ID == "" {
+					break
+				}
+				m.mode = "history"
+				m.history.SetItems([]list.Item{})
+				cmds = append(cmds, FetchHistory(m.auditRepo, storage.AuditTableReview, m.selectedReview.ID))
 			default:
 				m.logger.Log(fmt.Sprintf("key: %s", msg.String()))
 				m.reviewViewport, cmd = m.reviewViewport.Update(msg)
@@ -115,6 +144,10 @@ func (m *tabReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.logger.Log(fmt.Sprintf("stored review %s", msg))
 		cmds = append(cmds, m.inputQuality.Focus())
 		cmds = append(cmds, FetchNextUnratedReview(m.reviewRepo))
+	case Revisions:
+		m.logger.Log(fmt.Sprintf("found %d revisions", len(msg)))
+		m.history.SetItems(msg.listItems())
+		m.history.Select(0)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -130,11 +163,15 @@ func (m *tabReview) View() string {
 		Padding(1).
 		MaxHeight(m.height).
 		Render(m.ViewReview())
+	left := m.ViewForm()
+	if m.mode == "history" {
+		left = m.history.View()
+	}
 	colRate := lipgloss.NewStyle().
 		Width(colRateWidth).
 		Height(m.height).
 		Padding(1).
-		Render(m.ViewForm())
+		Render(left)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, colRate, colReview)
 }
@@ -206,10 +243,12 @@ func (m *tabReview) StoreReview() tea.Cmd {
 		}
 		//mentions := m.inputMentions.Value()
 
-		m.selectedReview.Quality = quality
-		//m.selectedReview.Mentions = strings.Split(mentions, ",")
-
-		if err := m.reviewRepo.Store(m.selectedReview); err != nil {
+		// the worker may have found the mentions in the meantime, then the
+		// quality goes on top of that
+		if err := storage.UpdateReview(context.Background(), m.reviewRepo, m.selectedReview, func(r *storage.Review) {
+			r.Quality = quality
+			//r.Mentions = strings.Split(mentions, ",")
+		}); err != nil {
 			return err
 		}
 
@@ -217,9 +256,27 @@ func (m *tabReview) StoreReview() tea.Cmd {
 	}
 }
 
-func FetchNextUnratedReview(reviewRepo *storage.ReviewRepository) tea.Cmd {
+// RestoreRevision stores the review as it was after the selected revision
+// and shows the result.
+func (m *tabReview) RestoreRevision() tea.Cmd {
+	rev, ok := m.history.SelectedItem().(Revision)
+	if !ok {
+		return nil
+	}
+	reviewID := m.selectedReview.ID
+	return func() tea.Msg {
+		review, err := storage.RestoreReview(context.Background(), m.reviewRepo, m.auditRepo, reviewID, rev.r.ID)
+		if err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("restored review %s to the version of %s", reviewID, rev.r.ChangedAt.Local().Format("2006-01-02 15:04")))
+		return review
+	}
+}
+
+func FetchNextUnratedReview(reviewRepo storage.ReviewRepository) tea.Cmd {
 	return func() tea.Msg {
-		review, err := reviewRepo.FindNextUnrated()
+		review, err := reviewRepo.FindNextUnrated(context.Background())
 		if err != nil {
 			return err
 		}
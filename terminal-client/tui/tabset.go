@@ -78,6 +78,14 @@ func (t *TabSet) Update(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// UpdateTab sends the message to the named tab, whether it is active or not.
+func (t *TabSet) UpdateTab(name string, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	t.tabs[name], cmd = t.tabs[name].Update(msg)
+
+	return cmd
+}
+
 func (t *TabSet) View() string {
 	var items []string
 	for i, name := range t.order {
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 
 	"go-mod.ewintr.nl/emdb/client"
@@ -13,8 +14,9 @@ import (
 )
 
 type tabTMDB struct {
-	movieRepo     *storage.MovieRepository
-	jobQueue      *job.JobQueue
+	db            storage.Backend
+	movieRepo     storage.MovieRepository
+	jobQueue      job.JobQueue
 	tmdb          *client.TMDB
 	initialized   bool
 	focused       string
@@ -23,9 +25,10 @@ type tabTMDB struct {
 	logger        *Logger
 }
 
-func NewTabTMDB(movieRepo *storage.MovieRepository, jobQueue *job.JobQueue, tmdb *client.TMDB, logger *Logger) (tea.Model, tea.Cmd) {
+func NewTabTMDB(db storage.Backend, jobQueue job.JobQueue, tmdb *client.TMDB, logger *Logger) (tea.Model, tea.Cmd) {
 	m := tabTMDB{
-		movieRepo: movieRepo,
+		db:        db,
+		movieRepo: db.Movies(),
 		jobQueue:  jobQueue,
 		tmdb:      tmdb,
 		logger:    logger,
@@ -72,10 +75,14 @@ func (m tabTMDB) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.searchInput.Blur()
 				m.Log("search tmdb...")
 			}
-		case "i":
+		case "i", "w":
 			if m.focused == "result" {
 				movie := m.searchResults.SelectedItem().(Movie)
 				movie.m.ID = uuid.New().String()
+				movie.m.Status = storage.StatusWatched
+				if msg.String() == "w" {
+					movie.m.Status = storage.StatusWantToWatch
+				}
 				cmds = append(cmds, m.ImportMovieCmd(movie), m.ResetCmd())
 				m.Log(fmt.Sprintf("imported movie %s", movie.Title()))
 			}
@@ -132,10 +139,12 @@ func (m *tabTMDB) SearchTMDBCmd(query string) tea.Cmd {
 
 func (m *tabTMDB) ImportMovieCmd(movie Movie) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.movieRepo.Store(movie.m); err != nil {
-			return err
-		}
-		if err := m.jobQueue.Add(movie.m.ID, string(job.ActionRefreshIMDBReviews)); err != nil {
+		if err := m.db.InTx(context.Background(), func(ctx context.Context) error {
+			if err := m.movieRepo.Store(ctx, movie.m); err != nil {
+				return err
+			}
+			return m.jobQueue.Enqueue(ctx, job.Job{ActionID: movie.m.ID, Action: job.ActionRefreshIMDBReviews, Priority: job.PriorityHigh})
+		}); err != nil {
 			return err
 		}
 
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/job"
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Trash []storage.Movie
+type TrashChanged struct{}
+type RestoredMovie storage.Movie
+
+type TrashedMovie struct {
+	m storage.Movie
+}
+
+func (m TrashedMovie) FilterValue() string {
+	return m.m.Title
+}
+
+func (m TrashedMovie) Title() string {
+	return fmt.Sprintf("%s (%d)", m.m.Title, m.m.Year)
+}
+
+func (m TrashedMovie) Description() string {
+	return fmt.Sprintf("deleted %s", m.m.DeletedAt.Local().Format("2006-01-02 15:04"))
+}
+
+func (t Trash) listItems() []list.Item {
+	items := []list.Item{}
+	for _, m := range t {
+		items = append(items, TrashedMovie{m: m})
+	}
+	return items
+}
+
+type tabTrash struct {
+	initialized bool
+	movieRepo   storage.MovieRepository
+	jobQueue    job.JobQueue
+	colWidth    int
+	colHeight   int
+	list        list.Model
+	logger      *Logger
+}
+
+func NewTabTrash(movieRepo storage.MovieRepository, jobQueue job.JobQueue, logger *Logger) (tea.Model, tea.Cmd) {
+	del := list.NewDefaultDelegate()
+	list := list.New([]list.Item{}, del, 0, 0)
+	list.Title = "Trash"
+	list.SetShowHelp(false)
+	list.SetFilteringEnabled(false)
+
+	m := tabTrash{
+		movieRepo: movieRepo,
+		jobQueue:  jobQueue,
+		list:      list,
+		logger:    logger,
+	}
+
+	return m, FetchTrash(movieRepo)
+}
+
+func (m tabTrash) Init() tea.Cmd {
+	return nil
+}
+
+func (m tabTrash) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case TabSizeMsg:
+		if !m.initialized {
+			m.initialized = true
+		}
+		m.colWidth = msg.Width / 2
+		m.colHeight = msg.Height
+		m.list.SetSize(m.colWidth, msg.Height-4)
+	case Trash:
+		m.logger.Log(fmt.Sprintf("found %d movies in the trash", len(msg)))
+		m.list.SetItems(msg.listItems())
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "right", "tab":
+			cmds = append(cmds, SelectNextTab())
+		case "left", "shift+tab":
+			cmds = append(cmds, SelectPrevTab())
+		case "up", "down":
+			m.list, cmd = m.list.Update(msg)
+			cmds = append(cmds, cmd)
+		case "r":
+			cmds = append(cmds, m.RestoreMovie())
+		case "p":
+			cmds = append(cmds, m.Purge())
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m tabTrash) View() string {
+	colLeft := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.list.View())
+	colRight := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.viewMovie())
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, colLeft, colRight)
+}
+
+func (m *tabTrash) viewMovie() string {
+	movie, ok := m.list.SelectedItem().(TrashedMovie)
+	if !ok {
+		return "The trash is empty."
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n\nPress r to restore it, together with its reviews and viewings.\nPress p to let the worker purge everything that has been in the trash for too long.", movie.m.Title, movie.m.Summary)
+}
+
+func (m *tabTrash) RestoreMovie() tea.Cmd {
+	movie, ok := m.list.SelectedItem().(TrashedMovie)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := m.movieRepo.Restore(context.Background(), movie.m.ID); err != nil {
+			return err
+		}
+		movie.m.DeletedAt = time.Time{}
+		return RestoredMovie(movie.m)
+	}
+}
+
+// Purge leaves the actual removal to the worker, that knows how long
+// movies are kept in the trash.
+func (m *tabTrash) Purge() tea.Cmd {
+	return func() tea.Msg {
+		if err := m.jobQueue.Enqueue(context.Background(), job.Job{Action: job.ActionPurgeTrash, Priority: job.PriorityHigh}); err != nil {
+			return err
+		}
+		m.logger.Log("added job to purge the trash")
+		return nil
+	}
+}
+
+func FetchTrash(movieRepo storage.MovieRepository) tea.Cmd {
+	return func() tea.Msg {
+		ms, err := movieRepo.FindDeleted(context.Background())
+		if err != nil {
+			return err
+		}
+		return Trash(ms)
+	}
+}
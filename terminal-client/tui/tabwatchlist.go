@@ -0,0 +1,313 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Watchlist []storage.Movie
+type StoredWatchlistMovie struct{}
+type PromotedMovie string
+
+type tabWatchlist struct {
+	initialized        bool
+	movieRepo          storage.MovieRepository
+	mode               string
+	colWidth           int
+	colHeight          int
+	list               list.Model
+	formLabels         []string
+	inputPriority      textinput.Model
+	inputRecommendedBy textinput.Model
+	formFocus          int
+	logger             *Logger
+}
+
+func NewTabWatchlist(movieRepo storage.MovieRepository, logger *Logger) (tea.Model, tea.Cmd) {
+	del := list.NewDefaultDelegate()
+	list := list.New([]list.Item{}, del, 0, 0)
+	list.Title = "Watchlist"
+	list.SetShowHelp(false)
+
+	formLabels := []string{
+		"Priority",
+		"Recommended by",
+	}
+
+	inputPriority := textinput.New()
+	inputPriority.Prompt = ""
+	inputPriority.Width = 50
+	inputPriority.CharLimit = 500
+	inputRecommendedBy := textinput.New()
+	inputRecommendedBy.Prompt = ""
+	inputRecommendedBy.Width = 50
+	inputRecommendedBy.CharLimit = 500
+
+	m := tabWatchlist{
+		movieRepo:          movieRepo,
+		logger:             logger,
+		mode:               "view",
+		list:               list,
+		formLabels:         formLabels,
+		inputPriority:      inputPriority,
+		inputRecommendedBy: inputRecommendedBy,
+	}
+
+	return m, FetchWatchlist(movieRepo)
+}
+
+func (m tabWatchlist) Init() tea.Cmd {
+	return nil
+}
+
+func (m tabWatchlist) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case TabSizeMsg:
+		if !m.initialized {
+			m.initialized = true
+		}
+		m.colWidth = msg.Width / 2
+		m.colHeight = msg.Height
+		m.list.SetSize(m.colWidth, msg.Height-4)
+		m.list, cmd = m.list.Update(msg)
+		cmds = append(cmds, cmd)
+	case Watchlist:
+		m.logger.Log(fmt.Sprintf("found %d movies on the watchlist", len(msg)))
+		m.list.SetItems(msg.listItems())
+		m.UpdateForm()
+		m.list, cmd = m.list.Update(msg)
+		cmds = append(cmds, cmd)
+	case StoredWatchlistMovie:
+		m.logger.Log("stored movie, fetching watchlist")
+		cmds = append(cmds, FetchWatchlist(m.movieRepo))
+	case tea.KeyMsg:
+		switch m.mode {
+		case "edit":
+			switch msg.String() {
+			case "tab", "shift+tab", "up", "down":
+				cmds = append(cmds, m.NavigateForm(msg.String())...)
+			case "esc":
+				m.mode = "view"
+				m.blurForm()
+				m.UpdateForm()
+			case "enter":
+				if _, err := strconv.Atoi(m.inputPriority.Value()); err != nil {
+					m.Log(fmt.Sprintf("ERROR: priority cannot be converted to an int: %s", err))
+					break
+				}
+				m.mode = "view"
+				m.blurForm()
+				cmds = append(cmds, m.StoreMovie())
+			default:
+				cmds = append(cmds, m.updateFormInputs(msg))
+			}
+		default:
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				return m, tea.Quit
+			case "right", "tab":
+				cmds = append(cmds, SelectNextTab())
+			case "left", "shift+tab":
+				cmds = append(cmds, SelectPrevTab())
+			case "up", "down":
+				m.list, cmd = m.list.Update(msg)
+				m.UpdateForm()
+				cmds = append(cmds, cmd)
+			case "e":
+				if _, ok := m.list.SelectedItem().(WatchlistMovie); !ok {
+					break
+				}
+				m.mode = "edit"
+				m.formFocus = 0
+				m.inputPriority.PromptStyle = focusedStyle
+				m.inputPriority.TextStyle = focusedStyle
+				cmds = append(cmds, m.inputPriority.Focus())
+			case "w":
+				cmds = append(cmds, m.ChangeStatus(storage.StatusWatched))
+			case "a":
+				cmds = append(cmds, m.ChangeStatus(storage.StatusAbandoned))
+			}
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m tabWatchlist) View() string {
+	colLeft := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.list.View())
+	colRight := lipgloss.NewStyle().
+		Width(m.colWidth).
+		Height(m.colHeight).
+		Render(m.ViewForm())
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, colLeft, colRight)
+}
+
+func (m *tabWatchlist) UpdateForm() {
+	movie, ok := m.list.SelectedItem().(WatchlistMovie)
+	if !ok {
+		return
+	}
+	m.inputPriority.SetValue(fmt.Sprintf("%d", movie.m.Priority))
+	m.inputRecommendedBy.SetValue(movie.m.RecommendedBy)
+}
+
+func (m *tabWatchlist) updateFormInputs(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch m.formFocus {
+	case 0:
+		m.inputPriority, cmd = m.inputPriority.Update(msg)
+	case 1:
+		m.inputRecommendedBy, cmd = m.inputRecommendedBy.Update(msg)
+	}
+	return cmd
+}
+
+func (m *tabWatchlist) NavigateForm(key string) []tea.Cmd {
+	var cmds []tea.Cmd
+	if key == "up" || key == "shift+tab" {
+		m.formFocus--
+	} else {
+		m.formFocus++
+	}
+	if m.formFocus >= len(m.formLabels) {
+		m.formFocus = 0
+	}
+	if m.formFocus < 0 {
+		m.formFocus = len(m.formLabels) - 1
+	}
+
+	m.blurForm()
+	switch m.formLabels[m.formFocus] {
+	case "Priority":
+		m.inputPriority.PromptStyle = focusedStyle
+		m.inputPriority.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputPriority.Focus())
+	case "Recommended by":
+		m.inputRecommendedBy.PromptStyle = focusedStyle
+		m.inputRecommendedBy.TextStyle = focusedStyle
+		cmds = append(cmds, m.inputRecommendedBy.Focus())
+	}
+
+	return cmds
+}
+
+func (m *tabWatchlist) blurForm() {
+	m.inputPriority.Blur()
+	m.inputRecommendedBy.Blur()
+}
+
+func (m *tabWatchlist) ViewForm() string {
+	movie, ok := m.list.SelectedItem().(WatchlistMovie)
+	if !ok {
+		return ""
+	}
+
+	labels := []string{
+		"Title: ",
+		"English title: ",
+		"Year: ",
+		"Runtime: ",
+		"Genres: ",
+		"TMDB rating: ",
+		"Directors: ",
+		"Summary: ",
+	}
+	for _, l := range m.formLabels {
+		labels = append(labels, fmt.Sprintf("%s: ", l))
+	}
+
+	fields := []string{
+		movie.m.Title,
+		movie.m.EnglishTitle,
+		fmt.Sprintf("%d", movie.m.Year),
+		viewRuntime(movie.m.Runtime),
+		strings.Join(movie.m.Genres, ", "),
+		viewVotes(movie.m.VoteAverage, movie.m.VoteCount),
+		strings.Join(storage.PersonNames(movie.m.Directors), ", "),
+		movie.m.Summary,
+		m.inputPriority.View(),
+		m.inputRecommendedBy.View(),
+	}
+
+	labelView := strings.Join(labels, "\n")
+	fieldsView := strings.Join(fields, "\n")
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, labelView, fieldsView)
+}
+
+func (m *tabWatchlist) StoreMovie() tea.Cmd {
+	return func() tea.Msg {
+		updated, ok := m.list.SelectedItem().(WatchlistMovie)
+		if !ok {
+			return nil
+		}
+		priority, err := strconv.Atoi(m.inputPriority.Value())
+		if err != nil {
+			return fmt.Errorf("priority cannot be converted to an int: %w", err)
+		}
+		recommendedBy := m.inputRecommendedBy.Value()
+		if err := storage.UpdateMovie(context.Background(), m.movieRepo, updated.m, func(mov *storage.Movie) {
+			mov.Priority = priority
+			mov.RecommendedBy = recommendedBy
+		}); err != nil {
+			return err
+		}
+		return StoredWatchlistMovie{}
+	}
+}
+
+// ChangeStatus takes the selected movie off the watchlist. A movie that
+// is watched gets today as watch date, so only the rating is left to fill in.
+func (m *tabWatchlist) ChangeStatus(status storage.Status) tea.Cmd {
+	movie, ok := m.list.SelectedItem().(WatchlistMovie)
+	if !ok {
+		return nil
+	}
+	m.list.RemoveItem(m.list.Index())
+	m.UpdateForm()
+
+	return func() tea.Msg {
+		if err := storage.UpdateMovie(context.Background(), m.movieRepo, movie.m, func(mov *storage.Movie) {
+			mov.Status = status
+			if status == storage.StatusWatched {
+				mov.WatchedOn = storage.Today()
+			}
+		}); err != nil {
+			return err
+		}
+		m.logger.Log(fmt.Sprintf("marked %s as %s", movie.m.Title, status))
+		if status != storage.StatusWatched {
+			return nil
+		}
+		return PromotedMovie(movie.m.ID)
+	}
+}
+
+func (m *tabWatchlist) Log(s string) {
+	m.logger.Log(s)
+}
+
+func FetchWatchlist(movieRepo storage.MovieRepository) tea.Cmd {
+	return func() tea.Msg {
+		ms, err := movieRepo.FindByStatus(context.Background(), storage.StatusWantToWatch)
+		if err != nil {
+			return err
+		}
+		return Watchlist(ms)
+	}
+}
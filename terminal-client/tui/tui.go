@@ -52,11 +52,11 @@ func SelectPrevTab() tea.Cmd {
 	}
 }
 
-func New(movieRepo *storage.MovieRepository, reviewRepo *storage.ReviewRepository, jobQueue *job.JobQueue, tmdb *client.TMDB, logger *Logger) (*tea.Program, error) {
+func New(db storage.Backend, jobQueue job.JobQueue, tmdb *client.TMDB, logger *Logger) (*tea.Program, error) {
 	logViewport := viewport.New(0, 0)
 	logViewport.KeyMap = viewport.KeyMap{}
 
-	m, _ := NewBaseModel(movieRepo, reviewRepo, jobQueue, tmdb, logger)
+	m, _ := NewBaseModel(db, jobQueue, tmdb, logger)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	return p, nil
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"fmt"
+
+	"go-mod.ewintr.nl/emdb/storage"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+type Viewing struct {
+	v     storage.Viewing
+	movie storage.Movie
+}
+
+func (v Viewing) FilterValue() string {
+	return v.movie.Title
+}
+
+func (v Viewing) Title() string {
+	return fmt.Sprintf("%s  %s (%d)", v.v.WatchedOn, v.movie.Title, v.movie.Year)
+}
+
+func (v Viewing) Description() string {
+	if v.v.Location == "" {
+		return fmt.Sprintf("rating %d", v.v.Rating)
+	}
+	return fmt.Sprintf("rating %d, %s", v.v.Rating, v.v.Location)
+}
+
+type Viewings []Viewing
+
+func (vs Viewings) listItems() []list.Item {
+	items := []list.Item{}
+	for _, v := range vs {
+		items = append(items, v)
+	}
+	return items
+}
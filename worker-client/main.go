@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"go-mod.ewintr.nl/emdb/client"
 	"go-mod.ewintr.nl/emdb/job"
@@ -13,28 +19,113 @@ import (
 	"go-mod.ewintr.nl/emdb/worker-client/worker"
 )
 
+const (
+	defaultTrashDays = 30
+	defaultLanes     = "simple=1,ai=1"
+)
+
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	dbHost := os.Getenv("EMDB_DB_HOST")
-	dbName := os.Getenv("EMDB_DB_NAME")
-	dbUser := os.Getenv("EMDB_DB_USER")
-	dbPassword := os.Getenv("EMDB_DB_PASSWORD")
-	pgConnStr := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbUser, dbPassword, dbName)
-	dbPostgres, err := storage.NewPostgres(pgConnStr)
+	var tmdb *client.TMDB
+	var err error
+	if key := os.Getenv("TMDB_API_KEY"); key != "" {
+		if tmdb, err = client.NewTMDB(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else {
+		logger.Warn("TMDB_API_KEY is not set, refreshing movies from TMDB will fail")
+	}
+	db, err := storage.Open(storage.ConfigFromEnv())
 	if err != nil {
-		fmt.Printf("could not create new postgres repo: %s", err.Error())
+		fmt.Printf("could not open database: %s", err.Error())
+		os.Exit(1)
+	}
+	jobQueue, err := job.NewJobQueue(db, logger)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	schedules, err := job.NewScheduleRepository(db)
+	if err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
-	movieRepo := storage.NewMovieRepository(dbPostgres)
-	reviewRepo := storage.NewReviewRepository(dbPostgres)
-	jobQueue := job.NewJobQueue(dbPostgres, logger)
 	ollama := client.NewOllama("http://localhost:11434")
+	trashDays := defaultTrashDays
+	if days := os.Getenv("EMDB_TRASH_DAYS"); days != "" {
+		if trashDays, err = strconv.Atoi(days); err != nil {
+			fmt.Printf("invalid EMDB_TRASH_DAYS: %s", err.Error())
+			os.Exit(1)
+		}
+		if trashDays < 0 {
+			fmt.Printf("invalid EMDB_TRASH_DAYS: %d is negative", trashDays)
+			os.Exit(1)
+		}
+	}
+	trashAge := time.Duration(trashDays) * 24 * time.Hour
+
+	name := os.Getenv("EMDB_WORKER")
+	if name == "" {
+		if name, err = defaultName(); err != nil {
+			fmt.Printf("could not make up a worker name, set EMDB_WORKER: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	lanesConfig := os.Getenv("EMDB_LANES")
+	if lanesConfig == "" {
+		lanesConfig = defaultLanes
+	}
+	lanes, err := parseLanes(lanesConfig)
+	if err != nil {
+		fmt.Printf("invalid EMDB_LANES: %s", err.Error())
+		os.Exit(1)
+	}
 
-	w := worker.NewWorker(jobQueue, movieRepo, reviewRepo, client.NewIMDB(), ollama, logger)
+	w := worker.NewWorker(name, jobQueue, schedules, db, client.NewIMDB(), tmdb, ollama, trashAge, lanes, logger)
 
-	go w.Run()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	w.Run(ctx)
+}
+
+// defaultName is unique for every run of the worker, also when several run
+// on the same host: the host name, the process id and a random part. As a
+// run never has the name of the one before, it cannot release the jobs that
+// the previous run left behind; they wait for their lease to run out.
+func defaultName() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(random)), nil
+}
+
+// parseLanes reads how many jobs of each type run at the same time, like
+// "simple=2,ai=1". Types that are left out get no jobs.
+func parseLanes(config string) (map[job.JobType]int, error) {
+	lanes := make(map[job.JobType]int)
+	for _, lane := range strings.Split(config, ",") {
+		name, count, ok := strings.Cut(strings.TrimSpace(lane), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not type=count", lane)
+		}
+		t := job.JobType(name)
+		if job.Actions(t) == nil {
+			return nil, fmt.Errorf("unknown job type %q", name)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid count for %s: %q", name, count)
+		}
+		lanes[t] = n
+	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	<-c
+	return lanes, nil
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"go-mod.ewintr.nl/emdb/job"
+)
+
+func TestParseLanes(t *testing.T) {
+	for config, exp := range map[string]map[job.JobType]int{
+		"simple=1,ai=1":      {job.TypeSimple: 1, job.TypeAI: 1},
+		" simple=4 , ai=0":   {job.TypeSimple: 4, job.TypeAI: 0},
+		"simple=2":           {job.TypeSimple: 2},
+		"ai=3,simple=1,ai=2": {job.TypeSimple: 1, job.TypeAI: 2},
+	} {
+		act, err := parseLanes(config)
+		if err != nil {
+			t.Errorf("%q: exp nil, got %v", config, err)
+			continue
+		}
+		if !maps.Equal(act, exp) {
+			t.Errorf("%q: exp %v, got %v", config, exp, act)
+		}
+	}
+
+	for _, config := range []string{"", "simple", "fast=1", "simple=many", "simple=-1"} {
+		if _, err := parseLanes(config); err == nil {
+			t.Errorf("%q: exp an error, got nil", config)
+		}
+	}
+}
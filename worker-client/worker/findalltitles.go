@@ -1,27 +1,31 @@
 package worker
 
 import (
+	"context"
+	"fmt"
+
 	"go-mod.ewintr.nl/emdb/job"
 )
 
-func (w *Worker) FindAllTitles(jobID int) {
+func (w *Worker) FindAllTitles(ctx context.Context, jobID int) error {
 	logger := w.logger.With("method", "findAllTitles", "jobID", jobID)
 
-	reviews, err := w.reviewRepo.FindAll()
+	reviews, err := w.reviewRepo.FindAll(ctx)
 	if err != nil {
-		logger.Error("could not get reviews", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not get reviews: %w", err)
 	}
 
-	for _, r := range reviews {
-		if err := w.jq.Add(r.ID, job.ActionFindTitles); err != nil {
-			logger.Error("could not add job", "error", err)
-			w.jq.MarkFailed(jobID)
-			return
+	if err := w.db.InTx(ctx, func(ctx context.Context) error {
+		for _, r := range reviews {
+			if err := w.jq.Add(ctx, r.ID, job.ActionFindTitles); err != nil {
+				return err
+			}
 		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not add jobs: %w", err)
 	}
 
 	logger.Info("find all titles", "count", len(reviews))
-	w.jq.MarkDone(jobID)
+	return nil
 }
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -23,21 +24,17 @@ If you found any movie titles other than %s, list them below in a JSON array. If
 Just answer with the JSON and nothing else. If you don't see any other movie titles, just use an empty JSON array.`
 )
 
-func (w *Worker) FindTitles(jobID int, reviewID string) {
+func (w *Worker) FindTitles(ctx context.Context, jobID int, reviewID string) error {
 	logger := w.logger.With("method", "findTitles", "jobID", jobID)
 
-	review, err := w.reviewRepo.FindOne(reviewID)
+	review, err := w.reviewRepo.FindOne(ctx, reviewID)
 	if err != nil {
-		logger.Error("could not get review", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not get review: %w", err)
 	}
 
-	movie, err := w.movieRepo.FindOne(review.MovieID)
+	movie, err := w.movieRepo.FindOne(ctx, review.MovieID)
 	if err != nil {
-		logger.Error("could not get movie", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not get movie: %w", err)
 	}
 
 	movieTitle := movie.Title
@@ -48,24 +45,21 @@ func (w *Worker) FindTitles(jobID int, reviewID string) {
 	prompt := fmt.Sprintf(mentionsTemplate, movieTitle, review.Review, movieTitle)
 	resp, err := w.ollama.Generate("mistral", prompt)
 	if err != nil {
-		logger.Error("could not find titles: %w", err)
+		return fmt.Errorf("could not find titles: %w", err)
 	}
 	logger.Info("checked review", "found", resp)
 	var mentions storage.TitleMentions
 	if err := json.Unmarshal([]byte(resp), &mentions); err != nil {
-		logger.Error("could not unmarshal llm response", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not unmarshal llm response: %w", err)
 	}
 
-	review.Mentions = mentions
-
-	if err := w.reviewRepo.Store(review); err != nil {
-		logger.Error("could not update review", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+	// the review may have been rated while waiting for the llm
+	if err := storage.UpdateReview(ctx, w.reviewRepo, review, func(r *storage.Review) {
+		r.Mentions = mentions
+	}); err != nil {
+		return fmt.Errorf("could not update review: %w", err)
 	}
 
 	logger.Info("done finding title mentions", "count", len(mentions.Titles))
-	w.jq.MarkDone(jobID)
+	return nil
 }
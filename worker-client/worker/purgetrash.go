@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (w *Worker) PurgeTrash(ctx context.Context, jobID int) error {
+	logger := w.logger.With("method", "purgeTrash", "jobID", jobID)
+
+	count, err := w.movieRepo.Purge(ctx, time.Now().Add(-w.trashAge))
+	if err != nil {
+		return fmt.Errorf("could not purge trash: %w", err)
+	}
+
+	logger.Info("purged trash", "count", count, "age", w.trashAge)
+	return nil
+}
@@ -1,28 +1,31 @@
 package worker
 
 import (
-	"time"
+	"context"
+	"fmt"
 
 	"go-mod.ewintr.nl/emdb/job"
 )
 
-func (w *Worker) RefreshAllReviews(jobID int) {
+func (w *Worker) RefreshAllReviews(ctx context.Context, jobID int) error {
 	logger := w.logger.With("method", "fetchReviews", "jobID", jobID)
 
-	movies, err := w.movieRepo.FindAll()
+	movies, err := w.movieRepo.FindAll(ctx)
 	if err != nil {
-		logger.Error("could not get movies", "error", err)
-		return
+		return fmt.Errorf("could not get movies: %w", err)
 	}
 
-	for _, m := range movies {
-		time.Sleep(1 * time.Second)
-		if err := w.jq.Add(m.ID, job.ActionRefreshIMDBReviews); err != nil {
-			logger.Error("could not add job", "error", err)
-			return
+	if err := w.db.InTx(ctx, func(ctx context.Context) error {
+		for _, m := range movies {
+			if err := w.jq.Add(ctx, m.ID, job.ActionRefreshIMDBReviews); err != nil {
+				return err
+			}
 		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not add jobs: %w", err)
 	}
 
 	logger.Info("refresh all reviews", "count", len(movies))
-	w.jq.MarkDone(jobID)
+	return nil
 }
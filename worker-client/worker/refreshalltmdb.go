@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"go-mod.ewintr.nl/emdb/job"
+)
+
+func (w *Worker) RefreshAllTMDB(ctx context.Context, jobID int) error {
+	logger := w.logger.With("method", "refreshAllTMDB", "jobID", jobID)
+
+	movies, err := w.movieRepo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get movies: %w", err)
+	}
+
+	if err := w.db.InTx(ctx, func(ctx context.Context) error {
+		for _, m := range movies {
+			if err := w.jq.Add(ctx, m.ID, job.ActionRefreshTMDB); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not add jobs: %w", err)
+	}
+
+	logger.Info("refresh all from tmdb", "count", len(movies))
+	return nil
+}
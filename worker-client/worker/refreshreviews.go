@@ -1,43 +1,49 @@
 package worker
 
-import "go-mod.ewintr.nl/emdb/job"
+import (
+	"context"
+	"fmt"
 
-func (w *Worker) RefreshReviews(jobID int, movieID string) {
+	"go-mod.ewintr.nl/emdb/job"
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+func (w *Worker) RefreshReviews(ctx context.Context, jobID int, movieID string) error {
 	logger := w.logger.With("method", "fetchReviews", "jobID", jobID, "movieID", movieID)
 
-	m, err := w.movieRepo.FindOne(movieID)
+	m, err := w.movieRepo.FindOne(ctx, movieID)
 	if err != nil {
-		logger.Error("could not get movie", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not get movie: %w", err)
 	}
-
-	if err := w.reviewRepo.DeleteByMovieID(m.ID); err != nil {
-		logger.Error("could not delete reviews", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+	if !m.DeletedAt.IsZero() {
+		logger.Info("movie is in the trash, nothing to refresh")
+		return nil
 	}
 
 	reviews, err := w.imdb.GetReviews(m)
 	if err != nil {
-		logger.Error("could not get reviews", "error", err)
-		w.jq.MarkFailed(jobID)
-		return
+		return fmt.Errorf("could not get reviews: %w", err)
 	}
 
-	for _, review := range reviews {
-		if err := w.reviewRepo.Store(review); err != nil {
-			logger.Error("could not store review", "error", err)
-			w.jq.MarkFailed(jobID)
-			return
+	// merge the reviews and queue the jobs of the new ones in one go, so the
+	// movie is never left with only part of them
+	var merge storage.ReviewMerge
+	if err := w.db.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		merge, err = storage.MergeReviews(ctx, w.reviewRepo, m.ID, storage.ReviewSourceIMDB, reviews)
+		if err != nil {
+			return fmt.Errorf("could not merge reviews: %w", err)
 		}
-		if err := w.jq.Add(review.ID, job.ActionFindTitles); err != nil {
-			logger.Error("could not add job", "error", err)
-			w.jq.MarkFailed(jobID)
-			return
+		for _, review := range merge.Added {
+			if err := w.jq.Add(ctx, review.ID, job.ActionFindTitles); err != nil {
+				return fmt.Errorf("could not add job: %w", err)
+			}
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	logger.Info("refresh reviews", "count", len(reviews))
-	w.jq.MarkDone(jobID)
+	logger.Info("refresh reviews", "added", len(merge.Added), "updated", len(merge.Updated), "vanished", len(merge.Vanished))
+	return nil
 }
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+func (w *Worker) RefreshTMDB(ctx context.Context, jobID int, movieID string) error {
+	logger := w.logger.With("method", "refreshTMDB", "jobID", jobID, "movieID", movieID)
+
+	m, err := w.movieRepo.FindOne(ctx, movieID)
+	if err != nil {
+		return fmt.Errorf("could not get movie: %w", err)
+	}
+	if !m.DeletedAt.IsZero() {
+		logger.Info("movie is in the trash, nothing to refresh")
+		return nil
+	}
+	if m.TMDBID == 0 {
+		logger.Info("movie has no tmdb id, nothing to refresh")
+		return nil
+	}
+	if w.tmdb == nil {
+		return fmt.Errorf("%w: no TMDB_API_KEY is set for this worker", storage.ErrValidation)
+	}
+
+	tm, err := w.tmdb.GetMovie(m.TMDBID)
+	if err != nil {
+		return fmt.Errorf("could not get movie from tmdb: %w", err)
+	}
+
+	refreshed := m
+	refreshed.ApplyTMDB(tm)
+	changes, err := storage.Diff(m, refreshed)
+	if err != nil {
+		return fmt.Errorf("could not compare movie: %w", err)
+	}
+	if len(changes) == 0 {
+		logger.Info("movie is up to date")
+		return nil
+	}
+	for _, c := range changes {
+		logger.Info("field changed", "field", c.Field, "old", c.Old, "new", c.New)
+	}
+
+	// the changes are recorded as a revision of their own, so they can be
+	// looked up and undone in the history of the movie
+	if err := storage.UpdateMovie(storage.WithActor(ctx, storage.ActorTMDB), w.movieRepo, m, func(m *storage.Movie) {
+		m.ApplyTMDB(tm)
+	}); err != nil {
+		return fmt.Errorf("could not store movie: %w", err)
+	}
+
+	logger.Info("refreshed movie from tmdb", "changes", len(changes))
+	return nil
+}
@@ -1,9 +1,11 @@
 package worker
 
 import (
-	"database/sql"
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"go-mod.ewintr.nl/emdb/client"
@@ -13,63 +15,258 @@ import (
 
 const (
 	interval = 5 * time.Second
+	// lease is how long a job stays with the worker without a heartbeat.
+	// After that, it is given to the next worker that asks.
+	lease     = time.Minute
+	heartbeat = lease / 3
 )
 
+var errLeaseLost = errors.New("lease lost")
+
 type Worker struct {
-	jq         *job.JobQueue
-	movieRepo  *storage.MovieRepository
-	reviewRepo *storage.ReviewRepository
+	name       string
+	jq         job.JobQueue
+	schedules  job.ScheduleRepository
+	db         storage.Backend
+	movieRepo  storage.MovieRepository
+	reviewRepo storage.ReviewRepository
 	imdb       *client.IMDB
+	tmdb       *client.TMDB
 	ollama     *client.Ollama
+	trashAge   time.Duration
+	lanes      map[job.JobType]int
 	logger     *slog.Logger
 }
 
-func NewWorker(jq *job.JobQueue, movieRepo *storage.MovieRepository, reviewRepo *storage.ReviewRepository, imdb *client.IMDB, ollama *client.Ollama, logger *slog.Logger) *Worker {
+// NewWorker creates a worker. The name identifies it in the job queue, so
+// every worker that shares a queue needs a name of its own. The lanes tell
+// how many jobs of each type it runs at the same time.
+func NewWorker(name string, jq job.JobQueue, schedules job.ScheduleRepository, db storage.Backend, imdb *client.IMDB, tmdb *client.TMDB, ollama *client.Ollama, trashAge time.Duration, lanes map[job.JobType]int, logger *slog.Logger) *Worker {
 	return &Worker{
+		name:       name,
 		jq:         jq,
-		movieRepo:  movieRepo,
-		reviewRepo: reviewRepo,
+		schedules:  schedules,
+		db:         db,
+		movieRepo:  db.Movies(),
+		reviewRepo: db.Reviews(),
 		imdb:       imdb,
+		tmdb:       tmdb,
 		ollama:     ollama,
-		logger:     logger.With("service", "worker"),
+		trashAge:   trashAge,
+		lanes:      lanes,
+		logger:     logger.With("service", "worker", "worker", name),
 	}
 }
 
-func (w *Worker) Run() {
+// Run handles jobs until the context is canceled. Every lane runs its own
+// number of jobs at the same time, so a queue full of slow AI jobs does not
+// hold up the simple ones.
+func (w *Worker) Run(ctx context.Context) {
 	logger := w.logger.With("method", "run")
-	logger.Info("starting worker")
+	logger.Info("starting worker", "lanes", w.lanes)
 
-	logger.Info("setting al existing jobs to todo")
-	if err := w.jq.ResetAll(); err != nil {
-		logger.Error("could not set all jobs to todo", "error", err)
+	// jobs that a previous run under the same name left behind are done
+	// again, jobs of other workers may still be running. This only finds
+	// something when the name is fixed with EMDB_WORKER, as the default
+	// name is new for every run. Otherwise Reap puts those jobs back when
+	// their lease runs out.
+	logger.Info("releasing jobs of a previous run")
+	if err := w.jq.Release(ctx, w.name); err != nil {
+		logger.Error("could not release jobs", "error", err)
 		return
 	}
 
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.housekeep(ctx)
+	}()
+	for t, n := range w.lanes {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(t job.JobType) {
+				defer wg.Done()
+				w.runLane(ctx, t)
+			}(t)
+		}
+	}
+	wg.Wait()
+	logger.Info("stopping worker")
+}
+
+// housekeep adds the jobs of schedules that are due and puts jobs with an
+// expired lease back in the queue.
+func (w *Worker) housekeep(ctx context.Context) {
+	logger := w.logger.With("method", "housekeep")
 	for {
-		time.Sleep(interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		w.materialize(ctx)
+		if n, err := w.jq.Reap(ctx); err != nil {
+			logger.Error("could not reap expired leases", "error", err)
+		} else if n > 0 {
+			logger.Warn("took back jobs with an expired lease", "count", n)
+		}
+	}
+}
 
-		j, err := w.jq.Next()
+// runLane handles the jobs with actions of the type, one at a time.
+func (w *Worker) runLane(ctx context.Context, t job.JobType) {
+	logger := w.logger.With("method", "runLane", "lane", t)
+	actions := job.Actions(t)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		j, err := w.jq.Next(ctx, w.name, lease, actions)
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			//logger.Info("no jobs found")
+		case errors.Is(err, storage.ErrNotFound):
 			continue
 		case err != nil:
 			logger.Error("could not get next job", "error", err)
 			continue
 		}
 
-		logger.Info("got a new job", "jobID", j.ID, "movieID", j.ActionID, "action", j.Action)
-		switch j.Action {
-		case job.ActionRefreshIMDBReviews:
-			w.RefreshReviews(j.ID, j.ActionID)
-		case job.ActionRefreshAllIMDBReviews:
-			w.RefreshAllReviews(j.ID)
-		case job.ActionFindTitles:
-			w.FindTitles(j.ID, j.ActionID)
-		case job.ActionFindAllTitles:
-			w.FindAllTitles(j.ID)
+		logger.Info("got a new job", "jobID", j.ID, "movieID", j.ActionID, "action", j.Action, "priority", j.Priority)
+		w.work(ctx, j)
+	}
+}
+
+// materialize adds a job for every schedule that is due and moves the
+// schedule to its next run. When several workers do this at the same time,
+// only one of them adds the job.
+func (w *Worker) materialize(ctx context.Context) {
+	logger := w.logger.With("method", "materialize")
+
+	now := time.Now()
+	due, err := w.schedules.FindDue(ctx, now)
+	if err != nil {
+		logger.Error("could not get due schedules", "error", err)
+		return
+	}
+	for _, s := range due {
+		next, err := s.Next(now)
+		if err != nil {
+			logger.Error("could not get next run of schedule", "schedule", s.Name, "error", err)
+			continue
+		}
+		err = w.db.InTx(ctx, func(ctx context.Context) error {
+			if err := w.schedules.Advance(ctx, s, next); err != nil {
+				return err
+			}
+			return w.jq.Enqueue(ctx, job.Job{ActionID: s.ActionID, Action: s.Action, RunAt: s.NextRun})
+		})
+		switch {
+		case errors.Is(err, storage.ErrConflict):
+			// another worker got there first
+		case err != nil:
+			logger.Error("could not add scheduled job", "schedule", s.Name, "error", err)
 		default:
-			logger.Error("unknown job action", "action", j.Action)
+			logger.Info("added scheduled job", "schedule", s.Name, "action", s.Action, "next", next)
+		}
+	}
+}
+
+// work handles the job and keeps the lease on it alive while it runs. When
+// the lease is lost, the job may already be with another worker, so the
+// handler is stopped and the job is left alone.
+func (w *Worker) work(ctx context.Context, j job.Job) {
+	logger := w.logger.With("method", "work", "jobID", j.ID, "action", j.Action)
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			err := w.jq.Heartbeat(ctx, j.ID, w.name, lease)
+			switch {
+			case errors.Is(err, storage.ErrNotFound):
+				cancel(errLeaseLost)
+				return
+			case err != nil:
+				logger.Warn("could not extend lease", "error", err)
+			}
 		}
+	}()
+
+	err := w.handle(jobCtx, j)
+	close(stop)
+	wg.Wait()
+	if errors.Is(context.Cause(jobCtx), errLeaseLost) {
+		logger.Warn("lost the lease on the job, leaving it alone", "error", err)
+		return
+	}
+	// the job is finished also when the worker is stopping, with a context
+	// that is not canceled yet
+	fctx := context.WithoutCancel(ctx)
+	if ctx.Err() != nil && err != nil {
+		logger.Info("stopped before the job was done, putting it back", "error", err)
+		w.jq.Retry(fctx, j.ID, w.name, 0, err)
+		return
+	}
+	w.finish(fctx, j, err)
+}
+
+func (w *Worker) handle(ctx context.Context, j job.Job) error {
+	switch j.Action {
+	case job.ActionRefreshIMDBReviews:
+		return w.RefreshReviews(ctx, j.ID, j.ActionID)
+	case job.ActionRefreshAllIMDBReviews:
+		return w.RefreshAllReviews(ctx, j.ID)
+	case job.ActionFindTitles:
+		return w.FindTitles(ctx, j.ID, j.ActionID)
+	case job.ActionFindAllTitles:
+		return w.FindAllTitles(ctx, j.ID)
+	case job.ActionRefreshTMDB:
+		return w.RefreshTMDB(ctx, j.ID, j.ActionID)
+	case job.ActionRefreshAllTMDB:
+		return w.RefreshAllTMDB(ctx, j.ID)
+	case job.ActionPurgeTrash:
+		return w.PurgeTrash(ctx, j.ID)
+	default:
+		return fmt.Errorf("%w: unknown job action %s", storage.ErrValidation, j.Action)
+	}
+}
+
+// finish marks the job by the kind of error. A record that is gone leaves
+// nothing to do and invalid input will not get better. Anything else is
+// tried again after a wait that grows with every attempt, until the action
+// runs out of attempts.
+func (w *Worker) finish(ctx context.Context, j job.Job, err error) {
+	logger := w.logger.With("method", "finish", "jobID", j.ID, "action", j.Action, "attempt", j.Attempts)
+	switch {
+	case err == nil:
+		w.jq.MarkDone(ctx, j.ID, w.name)
+	case errors.Is(err, storage.ErrNotFound):
+		logger.Info("skipping job, record not found", "error", err)
+		w.jq.MarkDone(ctx, j.ID, w.name)
+	case errors.Is(err, storage.ErrValidation):
+		logger.Error("job failed", "error", err)
+		w.jq.MarkFailed(ctx, j.ID, w.name, err)
+	case j.Attempts >= job.MaxAttempts(j.Action):
+		logger.Error("job failed, no attempts left", "error", err)
+		w.jq.MarkFailed(ctx, j.ID, w.name, err)
+	default:
+		wait := job.Backoff(j.Attempts)
+		logger.Warn("job will be retried", "error", err, "wait", wait)
+		w.jq.Retry(ctx, j.ID, w.name, wait, err)
 	}
 }
@@ -0,0 +1,216 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/emdb/job"
+	"go-mod.ewintr.nl/emdb/storage"
+)
+
+const testWorker = "test"
+
+// newTestWorker returns a worker on the memory backend, without clients.
+func newTestWorker(t *testing.T) (*Worker, *storage.Memory, *job.MemoryJobQueue) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mem := storage.NewMemory()
+	jq := job.NewMemoryJobQueue(logger)
+	w := NewWorker(testWorker, jq, job.NewMemoryScheduleRepository(), mem, nil, nil, nil, 30*24*time.Hour, map[job.JobType]int{}, logger)
+
+	return w, mem, jq
+}
+
+// countJobs returns how many jobs there are of each action.
+func countJobs(t *testing.T, jq job.JobQueue) map[string]int {
+	t.Helper()
+	jobs, err := jq.List(context.Background())
+	if err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	count := make(map[string]int)
+	for _, j := range jobs {
+		count[j.Action]++
+	}
+
+	return count
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	w, mem, jq := newTestWorker(t)
+	for _, m := range []storage.Movie{{ID: "ran", Title: "Ran"}, {ID: "ikiru", Title: "Ikiru"}} {
+		if err := mem.Movies().Store(ctx, m); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	for _, r := range []storage.Review{
+		{ID: "a", MovieID: "ran", Source: "imdb", URL: "a"},
+		{ID: "b", MovieID: "ran", Source: "imdb", URL: "b"},
+		{ID: "c", MovieID: "ikiru", Source: "imdb", URL: "c"},
+	} {
+		if err := mem.Reviews().Store(ctx, r); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+
+	if err := w.handle(ctx, job.Job{ID: 1, Action: job.ActionRefreshAllIMDBReviews}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := w.handle(ctx, job.Job{ID: 2, Action: job.ActionFindAllTitles}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	count := countJobs(t, jq)
+	if count[job.ActionRefreshIMDBReviews] != 2 {
+		t.Errorf("exp a refresh for every movie, got %d", count[job.ActionRefreshIMDBReviews])
+	}
+	if count[job.ActionFindTitles] != 3 {
+		t.Errorf("exp a search for titles for every review, got %d", count[job.ActionFindTitles])
+	}
+}
+
+func TestRefreshTMDB(t *testing.T) {
+	ctx := context.Background()
+	w, mem, jq := newTestWorker(t)
+	for _, m := range []storage.Movie{
+		{ID: "ran", Title: "Ran", TMDBID: 11645},
+		{ID: "home", Title: "Home video"},
+		{ID: "trashed", Title: "Trashed", TMDBID: 1},
+	} {
+		if err := mem.Movies().Store(ctx, m); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	if err := mem.Movies().Delete(ctx, "trashed"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	// nothing to do for movies that are not on TMDB or in the trash
+	for _, id := range []string{"home", "trashed"} {
+		if err := w.RefreshTMDB(ctx, 1, id); err != nil {
+			t.Errorf("%s: exp nil, got %v", id, err)
+		}
+	}
+	if err := w.RefreshTMDB(ctx, 1, "unknown"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	// this worker has no key
+	if err := w.RefreshTMDB(ctx, 1, "ran"); !errors.Is(err, storage.ErrValidation) {
+		t.Errorf("exp %v, got %v", storage.ErrValidation, err)
+	}
+
+	if err := w.handle(ctx, job.Job{ID: 2, Action: job.ActionRefreshAllTMDB}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if count := countJobs(t, jq); count[job.ActionRefreshTMDB] != 2 {
+		t.Errorf("exp a refresh for every movie outside the trash, got %d", count[job.ActionRefreshTMDB])
+	}
+}
+
+func TestFinish(t *testing.T) {
+	errOther := errors.New("connection refused")
+	// finish claims a job with the action, pretends it was tried that many
+	// times and returns it as it is after finishing with the error
+	finish := func(action string, attempts int, err error) (job.Job, bool) {
+		t.Helper()
+		ctx := context.Background()
+		w, _, jq := newTestWorker(t)
+		if err := jq.Add(ctx, "id", action); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+		j, err2 := jq.Next(ctx, testWorker, lease, []string{action})
+		if err2 != nil {
+			t.Fatalf("exp nil, got %v", err2)
+		}
+		j.Attempts = attempts
+		w.finish(ctx, j, err)
+
+		jobs, err2 := jq.List(ctx)
+		if err2 != nil {
+			t.Fatalf("exp nil, got %v", err2)
+		}
+		for _, act := range jobs {
+			if act.ID == j.ID {
+				return act, true
+			}
+		}
+		return job.Job{}, false
+	}
+
+	if act, ok := finish(job.ActionRefreshTMDB, 1, nil); ok {
+		t.Errorf("exp a job without an error to be done, got %v", act)
+	}
+	if act, ok := finish(job.ActionRefreshTMDB, 1, fmt.Errorf("could not get movie: %w", storage.ErrNotFound)); ok {
+		t.Errorf("exp a job for a missing movie to be skipped, got %v", act)
+	}
+
+	invalid := fmt.Errorf("%w: no key", storage.ErrValidation)
+	if act, _ := finish(job.ActionRefreshTMDB, 1, invalid); act.Status != "failed" || act.LastError != invalid.Error() {
+		t.Errorf("exp an invalid job to fail right away, got %v", act)
+	}
+
+	for _, attempts := range []int{1, 4} {
+		before := time.Now()
+		act, _ := finish(job.ActionRefreshTMDB, attempts, errOther)
+		if act.Status != "todo" || act.LastError != errOther.Error() {
+			t.Errorf("exp a retry after attempt %d, got %v", attempts, act)
+		}
+		wait := job.Backoff(attempts)
+		if act.RunAt.Before(before.Add(wait)) || act.RunAt.After(time.Now().Add(wait)) {
+			t.Errorf("exp a run after %v, got %v", wait, act.RunAt.Sub(before))
+		}
+	}
+
+	// five attempts for most jobs, three for the ones that ask Ollama
+	if act, _ := finish(job.ActionRefreshTMDB, 5, errOther); act.Status != "failed" {
+		t.Errorf("exp the job to fail after 5 attempts, got %v", act)
+	}
+	if act, _ := finish(job.ActionFindTitles, 3, errOther); act.Status != "failed" {
+		t.Errorf("exp the job to fail after 3 attempts, got %v", act)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	w, mem, _ := newTestWorker(t)
+	for _, m := range []storage.Movie{{ID: "ran", Title: "Ran"}, {ID: "trashed", Title: "Trashed"}} {
+		if err := mem.Movies().Store(ctx, m); err != nil {
+			t.Fatalf("exp nil, got %v", err)
+		}
+	}
+	if err := mem.Reviews().Store(ctx, storage.Review{ID: "r", MovieID: "trashed", Source: "imdb", URL: "r"}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if err := mem.Movies().Delete(ctx, "trashed"); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+
+	// the trash is not old enough yet
+	w.trashAge = time.Hour
+	if err := w.handle(ctx, job.Job{ID: 1, Action: job.ActionPurgeTrash}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if _, err := mem.Movies().FindOne(ctx, "trashed"); err != nil {
+		t.Errorf("exp the movie to stay in the trash, got %v", err)
+	}
+
+	w.trashAge = -time.Hour
+	if err := w.handle(ctx, job.Job{ID: 2, Action: job.ActionPurgeTrash}); err != nil {
+		t.Fatalf("exp nil, got %v", err)
+	}
+	if _, err := mem.Movies().FindOne(ctx, "trashed"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp %v, got %v", storage.ErrNotFound, err)
+	}
+	if _, err := mem.Reviews().FindOne(ctx, "r"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("exp the review to be gone with its movie, got %v", err)
+	}
+	if _, err := mem.Movies().FindOne(ctx, "ran"); err != nil {
+		t.Errorf("exp the other movie to stay, got %v", err)
+	}
+}